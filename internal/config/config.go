@@ -1,20 +1,64 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	GitHub struct {
-		APIKey string `yaml:"api_key"`
+		APIKey     string   `yaml:"api_key"`
+		APIKeys    []string `yaml:"api_keys"`    // Дополнительные токены, между которыми распределяются запросы
+		BaseURL    string   `yaml:"base_url"`    // Адрес API: пусто для github.com или адрес GitHub Enterprise Server
+		APIVersion string   `yaml:"api_version"` // Значение заголовка X-GitHub-Api-Version, пусто - значение по умолчанию
+		// Сколько можно ждать сброса лимита запросов, прежде чем вернуть клиенту 429
+		MaxRateLimitWait time.Duration `yaml:"max_rate_limit_wait"`
+		PageConcurrency  int           `yaml:"page_concurrency"` // Сколько страниц списка загружать параллельно
+		// Повторные попытки при сетевых ошибках, ответах 5xx и лимитах запросов
+		Retry struct {
+			MaxAttempts int           `yaml:"max_attempts"`
+			BaseDelay   time.Duration `yaml:"base_delay"`
+			MaxDelay    time.Duration `yaml:"max_delay"`
+			Jitter      float64       `yaml:"jitter"`
+		} `yaml:"retry"`
+		// Бэкенд API для каждого типа проверок: rest (по умолчанию) или graphql
+		Backends struct {
+			Followers string `yaml:"followers"`
+			Stars     string `yaml:"stars"`
+		} `yaml:"backends"`
+		// Аутентификация через GitHub App вместо персональных токенов
+		App struct {
+			AppID          int64  `yaml:"app_id"`
+			InstallationID int64  `yaml:"installation_id"`
+			PrivateKeyPath string `yaml:"private_key_path"` // Путь к PEM-файлу закрытого ключа приложения
+		} `yaml:"app"`
 	} `yaml:"github"`
 	Database struct {
 		Path string `yaml:"path"`
+		// Сколько хранить ответ GitHub в http_cache без подтверждения, по умолчанию 168h
+		HTTPCacheTTL time.Duration `yaml:"http_cache_ttl"`
+		// Максимум ответов в http_cache, по умолчанию 50000
+		HTTPCacheMaxEntries int `yaml:"http_cache_max_entries"`
 	} `yaml:"database"`
+	Server struct {
+		Addr            string        `yaml:"addr"`             // Адрес, на котором слушает сервер, по умолчанию :8080
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // Сколько ждать завершения запросов при остановке, по умолчанию 30s
+		RequestTimeout  time.Duration `yaml:"request_timeout"`  // Срок обработки одного запроса, 0 - без ограничения
+		// Минимальный остаток квоты GitHub, при котором /readyz отвечает готовностью, 0 - не проверять
+		ReadyMinQuota int `yaml:"ready_min_quota"`
+		// Токен для административных маршрутов /admin/..., пусто - маршруты отключены
+		AdminToken string `yaml:"admin_token"`
+	} `yaml:"server"`
+	// Пакетные проверки POST /api/v1/batch
+	Batch struct {
+		MaxItems    int `yaml:"max_items"`   // Максимум проверок в одном запросе
+		Concurrency int `yaml:"concurrency"` // Сколько групп проверок обрабатывать параллельно
+	} `yaml:"batch"`
 	FollowerUpdateInterval time.Duration `yaml:"follower_check_interval"`
 	Logging                struct {
 		FileLevel    string `yaml:"file_level"`
@@ -45,6 +89,37 @@ func LoadConfig(path string) error {
 		return err
 	}
 
+	if err := AppConfig.validateGitHubApp(); err != nil {
+		slog.Error("Invalid github.app in config file", "error", err)
+		return err
+	}
+
 	slog.Info("Loaded config successfully")
 	return nil
 }
+
+// validateGitHubApp проверяет, что для GitHub App заданы все параметры: иначе ошибка проявится только при первом обмене токена
+func (c *Config) validateGitHubApp() error {
+	app := c.GitHub.App
+	if app.AppID == 0 {
+		if app.InstallationID != 0 || app.PrivateKeyPath != "" {
+			return errors.New("github.app.app_id is required when github.app.installation_id or github.app.private_key_path is set")
+		}
+		return nil
+	}
+
+	var missing []string
+	if app.InstallationID <= 0 {
+		missing = append(missing, "github.app.installation_id")
+	}
+	if app.PrivateKeyPath == "" {
+		missing = append(missing, "github.app.private_key_path")
+	}
+	switch len(missing) {
+	case 1:
+		return fmt.Errorf("github.app.app_id is set, but %s is missing", missing[0])
+	case 2:
+		return fmt.Errorf("github.app.app_id is set, but %s are missing", strings.Join(missing, " and "))
+	}
+	return nil
+}
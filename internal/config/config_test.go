@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateGitHubApp(t *testing.T) {
+	tests := []struct {
+		name           string
+		appID          int64
+		installationID int64
+		privateKeyPath string
+		wantErr        string
+	}{
+		{name: "not configured"},
+		{name: "complete", appID: 1, installationID: 2, privateKeyPath: "app.pem"},
+		{name: "missing installation id", appID: 1, privateKeyPath: "app.pem", wantErr: "github.app.installation_id is missing"},
+		{name: "missing private key", appID: 1, installationID: 2, wantErr: "github.app.private_key_path is missing"},
+		{name: "missing both", appID: 1, wantErr: "github.app.installation_id and github.app.private_key_path are missing"},
+		{name: "negative installation id", appID: 1, installationID: -2, privateKeyPath: "app.pem", wantErr: "github.app.installation_id"},
+		{name: "missing app id", installationID: 2, privateKeyPath: "app.pem", wantErr: "github.app.app_id is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cfg.GitHub.App.AppID = tt.appID
+			cfg.GitHub.App.InstallationID = tt.installationID
+			cfg.GitHub.App.PrivateKeyPath = tt.privateKeyPath
+
+			err := cfg.validateGitHubApp()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
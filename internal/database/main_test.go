@@ -0,0 +1,27 @@
+package database
+
+import (
+	"gh-checker/internal/lib/logger"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain открывает базу во временном каталоге: функции пакета работают с общим соединением DB
+func TestMain(m *testing.M) {
+	if err := logger.InitializeLogger(logger.LogConfig{FilePath: os.DevNull}); err != nil {
+		panic(err)
+	}
+	dir, err := os.MkdirTemp("", "gh-checker-db")
+	if err != nil {
+		panic(err)
+	}
+	if err := InitDB(filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
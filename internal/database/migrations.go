@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"strings"
+)
+
+// migration - шаг изменения схемы или данных. Номер шага хранится в PRAGMA user_version.
+type migration struct {
+	name string
+	up   func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations применяются по порядку; уже применённые шаги не повторяются.
+// Новые шаги добавляются только в конец списка.
+var migrations = []migration{
+	{name: "lowercase logins", up: lowercaseLogins},
+	{name: "key relationships on user ids", up: keyOnUserIDs},
+	{name: "per-repository stargazer cache", up: createStargazerCache},
+	{name: "index http cache by last update", up: indexHTTPCache},
+}
+
+// migrate применяет недостающие миграции, каждую в своей транзакции
+func migrate(ctx context.Context) error {
+	var version int
+	if err := DB.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		logger.Error("Failed to read schema version", err)
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		m := migrations[i]
+		tx, err := DB.BeginTx(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to begin migration "+m.name, err)
+			return err
+		}
+		if err := m.up(ctx, tx); err != nil {
+			tx.Rollback()
+			logger.Error("Failed to apply migration "+m.name, err)
+			return err
+		}
+		// PRAGMA не поддерживает параметры запроса
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			logger.Error("Failed to update schema version", err)
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			logger.Error("Failed to commit migration "+m.name, err)
+			return err
+		}
+		logger.Info(fmt.Sprintf("Applied migration %d: %s", i+1, m.name))
+	}
+
+	return nil
+}
+
+// lowercaseLogins приводит сохранённые логины и репозитории к нижнему регистру.
+// Строки, которые после приведения совпали с уже существующими, удаляются как дубликаты.
+func lowercaseLogins(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		"UPDATE OR IGNORE followers SET username = lower(username), follower = lower(follower)",
+		"DELETE FROM followers WHERE username != lower(username) OR follower != lower(follower)",
+		"UPDATE OR IGNORE stars SET username = lower(username), repository = lower(repository)",
+		"DELETE FROM stars WHERE username != lower(username) OR repository != lower(repository)",
+		"UPDATE OR IGNORE last_check SET username = lower(username), repository = lower(repository)",
+		"DELETE FROM last_check WHERE username != lower(username) OR repository != lower(repository)",
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyOnUserIDs добавляет таблицу users и переводит связи на числовые ID пользователей GitHub.
+// Для старых строк ID неизвестны, поэтому кэш подписчиков и звёзд сбрасывается и заполняется заново при следующих проверках.
+func keyOnUserIDs(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY,
+		login TEXT NOT NULL UNIQUE,
+		last_updated TIMESTAMP
+	);
+	DROP TABLE IF EXISTS followers;
+	CREATE TABLE followers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		follower_id INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		follower TEXT NOT NULL,
+		last_updated TIMESTAMP,
+		UNIQUE(user_id, follower_id)
+	);
+	DROP TABLE IF EXISTS stars;
+	CREATE TABLE stars (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		repository TEXT NOT NULL,
+		last_updated TIMESTAMP,
+		UNIQUE(user_id, repository)
+	);
+	DROP TABLE IF EXISTS last_check;
+	CREATE TABLE last_check (
+		user_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		last_checked TIMESTAMP,
+		UNIQUE(user_id, repository)
+	);
+	`)
+	return err
+}
+
+// createStargazerCache добавляет кэш полного списка звёзд репозитория со своим временем проверки
+func createStargazerCache(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS stargazers (
+		repository TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		login TEXT NOT NULL,
+		last_updated TIMESTAMP,
+		UNIQUE(repository, user_id)
+	);
+	CREATE TABLE IF NOT EXISTS stargazers_check (
+		repository TEXT PRIMARY KEY,
+		last_checked TIMESTAMP
+	);
+	`)
+	return err
+}
+
+// indexHTTPCache добавляет индекс для вытеснения старых ответов из http_cache
+func indexHTTPCache(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS http_cache_last_updated ON http_cache(last_updated)")
+	return err
+}
+
+// canonical приводит логин или репозиторий к виду, в котором он хранится в базе.
+// Логины GitHub не зависят от регистра, поэтому храним их в нижнем регистре.
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
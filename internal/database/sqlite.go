@@ -1,8 +1,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
+	"strconv"
 	"sync"
 	"time"
 
@@ -29,10 +35,37 @@ func InitDB(dbPath string) error {
 		return err
 	}
 
+	if err = migrate(context.Background()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// createTables создаёт необходимые таблицы, если они не существуют
+// CloseDB закрывает соединение с базой данных, дождавшись завершения текущих записей
+func CloseDB() error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if DB == nil {
+		return nil
+	}
+	if err := DB.Close(); err != nil {
+		logger.Error("Failed to close database", err)
+		return err
+	}
+
+	logger.Info("Closed database connection")
+	return nil
+}
+
+// Ping проверяет, что база данных доступна
+func Ping(ctx context.Context) error {
+	defer metrics.ObserveDB("Ping")()
+	return DB.PingContext(ctx)
+}
+
+// createTables создаёт исходную схему, если таблиц ещё нет. Дальнейшие изменения схемы - в migrations.go.
 func createTables() error {
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS followers (
@@ -55,6 +88,13 @@ func createTables() error {
 		last_updated TIMESTAMP,
 		UNIQUE(username, repository)
 	);
+	CREATE TABLE IF NOT EXISTS http_cache (
+		url TEXT PRIMARY KEY,
+		etag TEXT NOT NULL,
+		headers TEXT,
+		body BLOB,
+		last_updated TIMESTAMP
+	);
 	`
 
 	_, err := DB.Exec(createTableSQL)
@@ -67,97 +107,49 @@ func createTables() error {
 	return nil
 }
 
-// AddFollower добавляет нового подписчика
-func AddFollower(username, follower string) error {
-	lock.Lock()
-	defer lock.Unlock()
-
-	stmt, err := DB.Prepare("INSERT OR IGNORE INTO followers(username, follower, last_updated) VALUES(?, ?, ?)")
-	if err != nil {
-		logger.Error("Error preparing statement for adding follower", err)
-		return err
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(username, follower, time.Now())
-	if err != nil {
-		logger.Error("Error executing statement for adding follower", err)
-		return err
-	}
-
-	logger.Info("Added/updated follower " + follower + " for user " + username)
-	return nil
-}
-
-// IsFollowing проверяет, является ли follower подписчиком username
-func IsFollowing(follower, username string) (bool, error) {
+// IsFollowing проверяет, является ли followerID подписчиком userID
+func IsFollowing(ctx context.Context, followerID, userID int64) (bool, error) {
+	defer metrics.ObserveDB("IsFollowing")()
 	lock.RLock()
 	defer lock.RUnlock()
 
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM followers WHERE username = ? AND follower = ?", username, follower).Scan(&count)
+	err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM followers WHERE user_id = ? AND follower_id = ?", userID, followerID).Scan(&count)
 	if err != nil {
 		logger.Error("Error checking if follower follows user", err)
 		return false, err
 	}
 
-	logger.Info("Checked if follower " + follower + " follows user " + username)
+	logger.Info("Checked if follower " + formatID(followerID) + " follows user " + formatID(userID))
 	return count > 0, nil
 }
 
-// UpdateLastChecked обновляет время последней проверки подписчиков для пользователя
-func UpdateLastChecked(username, recordType string) error {
-	lock.Lock()
-	defer lock.Unlock()
-
-	_, err := DB.Exec("INSERT OR REPLACE INTO last_check(username, repository, last_checked) VALUES(?, ?, ?)", username, recordType, time.Now())
-	if err != nil {
-		logger.Error("Error updating last checked time for user and record type", err)
-		return err
-	}
-
-	logger.Info("Updated last checked time for user " + username + " and record type " + recordType)
-	return nil
-}
-
-// UpdateLastCheckedFollowers обновляет время последней проверки подписчиков для пользователя
-func UpdateLastCheckedFollowers(username string) error {
-	lock.Lock()
-	defer lock.Unlock()
-
-	_, err := DB.Exec("INSERT OR REPLACE INTO last_check(username, repository, last_checked) VALUES(?, ?, ?)", username, "followers", time.Now())
-	if err != nil {
-		logger.Error("Error updating last checked time for user and followers", err)
-		return err
-	}
-
-	logger.Info("Updated last checked time for user " + username + " for followers")
-	return nil
-}
-
 // UpdateLastCheckedStars обновляет время последней проверки звезд для пользователя и репозитория
-func UpdateLastCheckedStars(username, repository string) error {
+func UpdateLastCheckedStars(ctx context.Context, userID int64, repository string) error {
+	defer metrics.ObserveDB("UpdateLastCheckedStars")()
+	repository = canonical(repository)
 	lock.Lock()
 	defer lock.Unlock()
 
-	_, err := DB.Exec("INSERT OR REPLACE INTO last_check(username, repository, last_checked) VALUES(?, ?, ?)", username, repository, time.Now())
+	_, err := DB.ExecContext(ctx, "INSERT OR REPLACE INTO last_check(user_id, repository, last_checked) VALUES(?, ?, ?)", userID, repository, time.Now())
 	if err != nil {
 		logger.Error("Error updating last checked time for user and repository", err)
 		return err
 	}
 
-	logger.Info("Updated last checked time for user " + username + " and repository " + repository)
+	logger.Info("Updated last checked time for user " + formatID(userID) + " and repository " + repository)
 	return nil
 }
 
-func ShouldUpdateFollowers(username string, updateInterval time.Duration) (bool, error) {
+func ShouldUpdateFollowers(ctx context.Context, userID int64, updateInterval time.Duration) (bool, error) {
+	defer metrics.ObserveDB("ShouldUpdateFollowers")()
 	lock.RLock()
 	defer lock.RUnlock()
 
 	var lastChecked time.Time
-	err := DB.QueryRow("SELECT last_checked FROM last_check WHERE username = ? AND repository = 'followers'", username).Scan(&lastChecked)
+	err := DB.QueryRowContext(ctx, "SELECT last_checked FROM last_check WHERE user_id = ? AND repository = 'followers'", userID).Scan(&lastChecked)
 	if err == sql.ErrNoRows {
-		logger.Info("No last checked time found for user " + username + ". Update required.")
+		logger.Info("No last checked time found for user " + formatID(userID) + ". Update required.")
 		return true, nil
 	} else if err != nil {
 		logger.Error("Error checking last checked time for user", err)
@@ -169,133 +161,299 @@ func ShouldUpdateFollowers(username string, updateInterval time.Duration) (bool,
 }
 
 // GetFollowers возвращает список подписчиков пользователя
-func GetFollowers(username string) ([]string, error) {
+func GetFollowers(ctx context.Context, userID int64) ([]models.User, error) {
+	defer metrics.ObserveDB("GetFollowers")()
 	lock.RLock()
 	defer lock.RUnlock()
 
-	rows, err := DB.Query("SELECT follower FROM followers WHERE username = ?", username)
+	rows, err := DB.QueryContext(ctx, "SELECT follower_id, follower FROM followers WHERE user_id = ?", userID)
 	if err != nil {
 		logger.Error("Error retrieving followers for user", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var followers []string
+	var followers []models.User
 	for rows.Next() {
-		var follower string
-		if err := rows.Scan(&follower); err != nil {
+		var follower models.User
+		if err := rows.Scan(&follower.ID, &follower.Login); err != nil {
 			logger.Error("Error scanning follower for user", err)
 			return nil, err
 		}
 		followers = append(followers, follower)
 	}
 
-	logger.Info("Retrieved " + string(len(followers)) + " followers for user " + username)
+	logger.Info("Retrieved " + strconv.Itoa(len(followers)) + " followers for user " + formatID(userID))
 	return followers, nil
 }
 
-// ClearFollowers удаляет всех подписчиков пользователя
-func ClearFollowers(username string) error {
+// ReplaceFollowers заменяет сохранённых подписчиков пользователя новым списком и обновляет время проверки.
+// Изменения вычисляются относительно текущих строк и записываются одной транзакцией, поэтому читатели
+// видят либо старый, либо новый список целиком, а при ошибке старый список сохраняется.
+func ReplaceFollowers(ctx context.Context, user models.User, followers []models.User) error {
+	defer metrics.ObserveDB("ReplaceFollowers")()
 	lock.Lock()
 	defer lock.Unlock()
 
-	_, err := DB.Exec("DELETE FROM followers WHERE username = ?", username)
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("Error starting transaction for replacing followers", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	username := canonical(user.Login)
+	added, removed, err := replaceUserSet(ctx, tx, user.ID, followers, userSetQueries{
+		list:   "SELECT follower_id, follower FROM followers WHERE user_id = ?",
+		upsert: "INSERT INTO followers(user_id, follower_id, username, follower, last_updated) VALUES(?, ?, ?, ?, ?) ON CONFLICT(user_id, follower_id) DO UPDATE SET username = excluded.username, follower = excluded.follower, last_updated = excluded.last_updated",
+		remove: "DELETE FROM followers WHERE user_id = ? AND follower_id IN (SELECT value FROM json_each(?))",
+	}, func(follower models.User) []any {
+		return []any{user.ID, follower.ID, username, canonical(follower.Login), now}
+	})
+	if err != nil {
+		logger.Error("Error replacing followers for user "+user.Login, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO last_check(user_id, repository, last_checked) VALUES(?, ?, ?)", user.ID, "followers", now)
 	if err != nil {
-		logger.Error("Error clearing followers for user", err)
+		logger.Error("Error updating last checked time for user and followers", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Error committing followers for user", err)
 		return err
 	}
 
-	logger.Info("Cleared followers for user " + username)
+	logger.Info(fmt.Sprintf("Replaced followers for user %s: %d added or renamed, %d removed, %d total", user.Login, added, removed, len(followers)))
 	return nil
 }
 
+// userSetQueries - запросы к таблице, где хранится множество пользователей одного владельца (пользователя или репозитория)
+type userSetQueries struct {
+	list   string // Текущие ID и логины по ключу владельца
+	upsert string // Добавление или обновление строки, аргументы - из upsertArgs
+	remove string // Удаление строк по ключу владельца и JSON-массиву ID
+}
+
+// replaceUserSet приводит множество пользователей владельца key к users внутри транзакции tx.
+// Записываются только изменения: новые пользователи, переименованные аккаунты и удалённые строки.
+func replaceUserSet(ctx context.Context, tx *sql.Tx, key any, users []models.User, queries userSetQueries, upsertArgs func(models.User) []any) (int, int, error) {
+	// Текущие строки: ID -> логин
+	existing := make(map[int64]string)
+	rows, err := tx.QueryContext(ctx, queries.list, key)
+	if err != nil {
+		return 0, 0, err
+	}
+	for rows.Next() {
+		var id int64
+		var login string
+		if err := rows.Scan(&id, &login); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		existing[id] = login
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	upsert, err := tx.PrepareContext(ctx, queries.upsert)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer upsert.Close()
+
+	// Новые пользователи и переименованные аккаунты
+	added := 0
+	for _, user := range users {
+		current, ok := existing[user.ID]
+		delete(existing, user.ID)
+		if ok && current == canonical(user.Login) {
+			continue
+		}
+		if _, err := upsert.ExecContext(ctx, upsertArgs(user)...); err != nil {
+			return 0, 0, err
+		}
+		added++
+	}
+
+	// В existing остались строки, которых больше нет в списке. Они удаляются одним запросом:
+	// ID передаются JSON-массивом, так как их число может превысить лимит параметров SQLite.
+	if len(existing) > 0 {
+		removed := make([]int64, 0, len(existing))
+		for id := range existing {
+			removed = append(removed, id)
+		}
+		ids, err := json.Marshal(removed)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, err := tx.ExecContext(ctx, queries.remove, key, string(ids)); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return added, len(existing), nil
+}
+
 // AddStar добавляет информацию о звезде пользователя на репозитории
-func AddStar(username, repository string) error {
+func AddStar(ctx context.Context, user models.User, repository string) error {
+	defer metrics.ObserveDB("AddStar")()
+	repository = canonical(repository)
 	lock.Lock()
 	defer lock.Unlock()
 
-	stmt, err := DB.Prepare("INSERT OR IGNORE INTO stars(username, repository, last_updated) VALUES(?, ?, ?)")
+	stmt, err := DB.PrepareContext(ctx, "INSERT OR REPLACE INTO stars(user_id, username, repository, last_updated) VALUES(?, ?, ?, ?)")
 	if err != nil {
 		logger.Error("Error preparing statement for adding star", err)
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(username, repository, time.Now())
+	_, err = stmt.ExecContext(ctx, user.ID, canonical(user.Login), repository, time.Now())
 	if err != nil {
 		logger.Error("Error executing statement for adding star", err)
 		return err
 	}
 
-	logger.Info("Added/updated star for user " + username + " on repository " + repository)
+	logger.Info("Added/updated star for user " + user.Login + " on repository " + repository)
 	return nil
 }
 
 // IsStarred проверяет, поставил ли пользователь звезду на репозиторий
-func IsStarred(username, repository string) (bool, error) {
+func IsStarred(ctx context.Context, userID int64, repository string) (bool, error) {
+	defer metrics.ObserveDB("IsStarred")()
+	repository = canonical(repository)
 	lock.RLock()
 	defer lock.RUnlock()
 
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM stars WHERE username = ? AND repository = ?", username, repository).Scan(&count)
+	err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM stars WHERE user_id = ? AND repository = ?", userID, repository).Scan(&count)
 	if err != nil {
 		logger.Error("Error checking if user starred repository", err)
 		return false, err
 	}
 
-	logger.Info("Checked if user " + username + " starred repository " + repository)
+	logger.Info("Checked if user " + formatID(userID) + " starred repository " + repository)
 	return count > 0, nil
 }
 
-// ClearStars удаляет все звезды пользователя на репозитории
-func ClearStars(username string) error {
+// RemoveStar удаляет звезду пользователя на одном репозитории, не затрагивая остальные
+func RemoveStar(ctx context.Context, userID int64, repository string) error {
+	defer metrics.ObserveDB("RemoveStar")()
+	repository = canonical(repository)
 	lock.Lock()
 	defer lock.Unlock()
 
-	_, err := DB.Exec("DELETE FROM stars WHERE username = ?", username)
+	_, err := DB.ExecContext(ctx, "DELETE FROM stars WHERE user_id = ? AND repository = ?", userID, repository)
 	if err != nil {
-		logger.Error("Error clearing stars for user", err)
+		logger.Error("Error removing star for user", err)
 		return err
 	}
 
-	logger.Info("Cleared stars for user " + username)
+	logger.Info("Removed star for user " + formatID(userID) + " on repository " + repository)
 	return nil
 }
 
-// GetLastChecked возвращает время последней проверки для пользователя и репозитория
-func GetLastChecked(username, repository string) (time.Time, error) {
+func ShouldUpdateStars(ctx context.Context, userID int64, repository string, updateInterval time.Duration) (bool, error) {
+	defer metrics.ObserveDB("ShouldUpdateStars")()
+	repository = canonical(repository)
 	lock.RLock()
 	defer lock.RUnlock()
 
 	var lastChecked time.Time
-	err := DB.QueryRow("SELECT last_checked FROM last_check WHERE username = ? AND repository = ?", username, repository).Scan(&lastChecked)
+	err := DB.QueryRowContext(ctx, "SELECT last_checked FROM last_check WHERE user_id = ? AND repository = ?", userID, repository).Scan(&lastChecked)
+	if err == sql.ErrNoRows {
+		logger.Info("No last checked time found for user " + formatID(userID) + " and repository " + repository + ". Update required.")
+		return true, nil
+	} else if err != nil {
+		logger.Error("Error checking last checked time for user and repository", err)
+		return false, err
+	}
+
+	timeSinceLastCheck := time.Since(lastChecked)
+	return timeSinceLastCheck > updateInterval, nil
+}
+
+// GetCachedResponse возвращает сохранённые ETag, заголовки и тело ответа GitHub API для URL
+func GetCachedResponse(ctx context.Context, url string) (string, string, []byte, error) {
+	defer metrics.ObserveDB("GetCachedResponse")()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var etag, headers string
+	var body []byte
+	err := DB.QueryRowContext(ctx, "SELECT etag, headers, body FROM http_cache WHERE url = ?", url).Scan(&etag, &headers, &body)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.Info("No last checked time found for user " + username + " and repository " + repository)
-			return time.Time{}, sql.ErrNoRows
+			return "", "", nil, sql.ErrNoRows
 		}
-		logger.Error("Error retrieving last checked time for user and repository", err)
-		return time.Time{}, err
+		logger.Error("Error retrieving cached response for url "+url, err)
+		return "", "", nil, err
 	}
 
-	logger.Info("Retrieved last checked time for user " + username + " and repository " + repository)
-	return lastChecked, nil
+	logger.Debug("Retrieved cached response for url " + url)
+	return etag, headers, body, nil
 }
 
-func ShouldUpdateStars(username, repository string, updateInterval time.Duration) (bool, error) {
-	lock.RLock()
-	defer lock.RUnlock()
+// SaveCachedResponse сохраняет ETag, заголовки и тело ответа GitHub API для URL
+func SaveCachedResponse(ctx context.Context, url, etag, headers string, body []byte) error {
+	defer metrics.ObserveDB("SaveCachedResponse")()
+	lock.Lock()
+	defer lock.Unlock()
 
-	var lastChecked time.Time
-	err := DB.QueryRow("SELECT last_checked FROM last_check WHERE username = ? AND repository = ?", username, repository).Scan(&lastChecked)
-	if err == sql.ErrNoRows {
-		logger.Info("No last checked time found for user " + username + " and repository " + repository + ". Update required.")
-		return true, nil
-	} else if err != nil {
-		logger.Error("Error checking last checked time for user and repository", err)
-		return false, err
+	_, err := DB.ExecContext(ctx, "INSERT OR REPLACE INTO http_cache(url, etag, headers, body, last_updated) VALUES(?, ?, ?, ?, ?)", url, etag, headers, body, time.Now())
+	if err != nil {
+		logger.Error("Error saving cached response for url "+url, err)
+		return err
 	}
 
-	timeSinceLastCheck := time.Since(lastChecked)
-	return timeSinceLastCheck > updateInterval, nil
+	logger.Debug("Saved cached response for url " + url)
+	return nil
+}
+
+// TouchCachedResponse отмечает, что сохранённый ответ снова подтверждён GitHub (304), чтобы он не был вытеснен
+func TouchCachedResponse(ctx context.Context, url string) error {
+	defer metrics.ObserveDB("TouchCachedResponse")()
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, err := DB.ExecContext(ctx, "UPDATE http_cache SET last_updated = ? WHERE url = ?", time.Now(), url)
+	if err != nil {
+		logger.Error("Error touching cached response for url "+url, err)
+		return err
+	}
+	return nil
+}
+
+// PruneCachedResponses удаляет ответы, не обновлявшиеся с olderThan, и самые старые сверх maxEntries (0 - без ограничения).
+// Возвращает число удалённых строк.
+func PruneCachedResponses(ctx context.Context, olderThan time.Time, maxEntries int) (int64, error) {
+	defer metrics.ObserveDB("PruneCachedResponses")()
+	lock.Lock()
+	defer lock.Unlock()
+
+	result, err := DB.ExecContext(ctx, "DELETE FROM http_cache WHERE last_updated < ?", olderThan)
+	if err != nil {
+		logger.Error("Error pruning expired cached responses", err)
+		return 0, err
+	}
+	pruned, _ := result.RowsAffected()
+
+	if maxEntries > 0 {
+		result, err = DB.ExecContext(ctx, "DELETE FROM http_cache WHERE url NOT IN (SELECT url FROM http_cache ORDER BY last_updated DESC LIMIT ?)", maxEntries)
+		if err != nil {
+			logger.Error("Error pruning cached responses over the limit", err)
+			return pruned, err
+		}
+		overLimit, _ := result.RowsAffected()
+		pruned += overLimit
+	}
+
+	logger.Info(fmt.Sprintf("Pruned %d cached responses", pruned))
+	return pruned, nil
 }
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"gh-checker/internal/models"
+	"testing"
+	"time"
+)
+
+func TestPruneCachedResponses(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		ages       []time.Duration // Возраст каждой сохранённой записи
+		ttl        time.Duration
+		maxEntries int
+		wantKept   []int // Индексы записей, которые должны остаться
+	}{
+		{name: "expired entries", ages: []time.Duration{time.Minute, 2 * time.Hour, 3 * time.Hour}, ttl: time.Hour, wantKept: []int{0}},
+		{name: "over the limit keeps newest", ages: []time.Duration{3 * time.Minute, time.Minute, 2 * time.Minute}, ttl: time.Hour, maxEntries: 2, wantKept: []int{1, 2}},
+		{name: "nothing to prune", ages: []time.Duration{time.Minute}, ttl: time.Hour, maxEntries: 10, wantKept: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DB.Exec("DELETE FROM http_cache"); err != nil {
+				t.Fatal(err)
+			}
+			for i, age := range tt.ages {
+				_, err := DB.Exec("INSERT INTO http_cache(url, etag, headers, body, last_updated) VALUES(?, ?, ?, ?, ?)", fmt.Sprintf("url-%d", i), "etag", "{}", []byte("[]"), now.Add(-age))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pruned, err := PruneCachedResponses(ctx, now.Add(-tt.ttl), tt.maxEntries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := int64(len(tt.ages) - len(tt.wantKept)); pruned != want {
+				t.Errorf("pruned %d entries, want %d", pruned, want)
+			}
+			for _, i := range tt.wantKept {
+				if _, _, _, err := GetCachedResponse(ctx, fmt.Sprintf("url-%d", i)); err != nil {
+					t.Errorf("url-%d was pruned: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+// Подтверждённый ответ не вытесняется по возрасту
+func TestTouchCachedResponse(t *testing.T) {
+	ctx := context.Background()
+	if _, err := DB.Exec("INSERT OR REPLACE INTO http_cache(url, etag, headers, body, last_updated) VALUES('touched', 'etag', '{}', '', ?)", time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := TouchCachedResponse(ctx, "touched"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PruneCachedResponses(ctx, time.Now().Add(-time.Hour), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := GetCachedResponse(ctx, "touched"); err != nil {
+		t.Errorf("touched response was pruned: %v", err)
+	}
+}
+
+func TestReplaceFollowers(t *testing.T) {
+	ctx := context.Background()
+	user := models.User{ID: 100, Login: "Owner"}
+
+	steps := []struct {
+		name      string
+		followers []models.User
+	}{
+		{name: "initial", followers: []models.User{{ID: 1, Login: "a"}, {ID: 2, Login: "b"}}},
+		{name: "rename and remove", followers: []models.User{{ID: 1, Login: "a-renamed"}, {ID: 3, Login: "c"}}},
+		{name: "empty", followers: nil},
+		// Удалений больше, чем SQLite допускает параметров в одном запросе
+		{name: "large", followers: manyUsers(40000)},
+		{name: "remove most", followers: manyUsers(1)},
+	}
+
+	for _, step := range steps {
+		if err := ReplaceFollowers(ctx, user, step.followers); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		got, err := GetFollowers(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		want := make(map[int64]string, len(step.followers))
+		for _, follower := range step.followers {
+			want[follower.ID] = follower.Login
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.followers)
+		}
+		for _, follower := range got {
+			if want[follower.ID] != follower.Login {
+				t.Errorf("%s: follower %d = %q, want %q", step.name, follower.ID, follower.Login, want[follower.ID])
+			}
+		}
+
+		shouldUpdate, err := ShouldUpdateFollowers(ctx, user.ID, time.Hour)
+		if err != nil || shouldUpdate {
+			t.Errorf("%s: ShouldUpdateFollowers = %v, %v; want fresh", step.name, shouldUpdate, err)
+		}
+	}
+}
+
+// manyUsers возвращает n пользователей с ID от 1 до n
+func manyUsers(n int) []models.User {
+	users := make([]models.User, n)
+	for i := range users {
+		users[i] = models.User{ID: int64(i + 1), Login: fmt.Sprintf("user-%d", i+1)}
+	}
+	return users
+}
+
+func TestReplaceStargazers(t *testing.T) {
+	ctx := context.Background()
+	repository := "owner/replace-stargazers"
+
+	if err := ReplaceStargazers(ctx, repository, manyUsers(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceStargazers(ctx, repository, []models.User{{ID: 2, Login: "user-2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[int64]bool{1: false, 2: true, 3: false} {
+		got, err := IsStargazer(ctx, repository, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("IsStargazer(%d) = %v, want %v", id, got, want)
+		}
+	}
+}
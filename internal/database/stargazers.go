@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
+	"time"
+)
+
+// ReplaceStargazers заменяет кэш звёзд репозитория и время его проверки одной транзакцией.
+// Записываются только изменения, поэтому повторная загрузка большого списка почти не нагружает базу.
+func ReplaceStargazers(ctx context.Context, repository string, stargazers []models.User) error {
+	defer metrics.ObserveDB("ReplaceStargazers")()
+	repository = canonical(repository)
+	lock.Lock()
+	defer lock.Unlock()
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("Error starting transaction for replacing stargazers", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	added, removed, err := replaceUserSet(ctx, tx, repository, stargazers, userSetQueries{
+		list:   "SELECT user_id, login FROM stargazers WHERE repository = ?",
+		upsert: "INSERT INTO stargazers(repository, user_id, login, last_updated) VALUES(?, ?, ?, ?) ON CONFLICT(repository, user_id) DO UPDATE SET login = excluded.login, last_updated = excluded.last_updated",
+		remove: "DELETE FROM stargazers WHERE repository = ? AND user_id IN (SELECT value FROM json_each(?))",
+	}, func(stargazer models.User) []any {
+		return []any{repository, stargazer.ID, canonical(stargazer.Login), now}
+	})
+	if err != nil {
+		logger.Error("Error replacing stargazers for repository "+repository, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO stargazers_check(repository, last_checked) VALUES(?, ?)", repository, now)
+	if err != nil {
+		logger.Error("Error updating last checked time for repository stargazers", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Error committing stargazers for repository", err)
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Replaced stargazers for repository %s: %d added or renamed, %d removed, %d total", repository, added, removed, len(stargazers)))
+	return nil
+}
+
+// IsStargazer проверяет по кэшу звёзд репозитория, поставил ли пользователь звезду
+func IsStargazer(ctx context.Context, repository string, userID int64) (bool, error) {
+	defer metrics.ObserveDB("IsStargazer")()
+	repository = canonical(repository)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var count int
+	err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM stargazers WHERE repository = ? AND user_id = ?", repository, userID).Scan(&count)
+	if err != nil {
+		logger.Error("Error checking if user is a cached stargazer", err)
+		return false, err
+	}
+
+	logger.Info("Checked if user " + formatID(userID) + " is a stargazer of repository " + repository)
+	return count > 0, nil
+}
+
+// ShouldUpdateStargazers проверяет, устарел ли кэш звёзд репозитория
+func ShouldUpdateStargazers(ctx context.Context, repository string, updateInterval time.Duration) (bool, error) {
+	defer metrics.ObserveDB("ShouldUpdateStargazers")()
+	repository = canonical(repository)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var lastChecked time.Time
+	err := DB.QueryRowContext(ctx, "SELECT last_checked FROM stargazers_check WHERE repository = ?", repository).Scan(&lastChecked)
+	if err == sql.ErrNoRows {
+		logger.Info("No stargazers cached for repository " + repository + ". Update required.")
+		return true, nil
+	} else if err != nil {
+		logger.Error("Error checking last checked time for repository stargazers", err)
+		return false, err
+	}
+
+	return time.Since(lastChecked) > updateInterval, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
+	"strconv"
+	"time"
+)
+
+// SaveUser запоминает текущий логин пользователя.
+// Если логин раньше принадлежал другому ID (аккаунт переименовали, а логин занял кто-то другой), старая запись удаляется.
+func SaveUser(ctx context.Context, user models.User) error {
+	defer metrics.ObserveDB("SaveUser")()
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, err := DB.ExecContext(ctx, "INSERT OR REPLACE INTO users(id, login, last_updated) VALUES(?, ?, ?)", user.ID, canonical(user.Login), time.Now())
+	if err != nil {
+		logger.Error("Error saving user "+user.Login, err)
+		return err
+	}
+
+	logger.Debug("Saved user " + user.Login + " with id " + formatID(user.ID))
+	return nil
+}
+
+// GetUserByLogin возвращает пользователя по логину и время, когда сопоставление логина и ID было проверено
+func GetUserByLogin(ctx context.Context, login string) (models.User, time.Time, error) {
+	defer metrics.ObserveDB("GetUserByLogin")()
+	return getUser(ctx, "SELECT id, login, last_updated FROM users WHERE login = ?", canonical(login))
+}
+
+// GetUserByID возвращает пользователя по ID и время, когда сопоставление логина и ID было проверено
+func GetUserByID(ctx context.Context, id int64) (models.User, time.Time, error) {
+	defer metrics.ObserveDB("GetUserByID")()
+	return getUser(ctx, "SELECT id, login, last_updated FROM users WHERE id = ?", id)
+}
+
+// getUser выполняет запрос к таблице users. Если пользователя нет, возвращает sql.ErrNoRows.
+func getUser(ctx context.Context, query string, arg any) (models.User, time.Time, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var user models.User
+	var lastUpdated time.Time
+	err := DB.QueryRowContext(ctx, query, arg).Scan(&user.ID, &user.Login, &lastUpdated)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Error("Error retrieving user", err)
+		}
+		return models.User{}, time.Time{}, err
+	}
+
+	return user, lastUpdated, nil
+}
+
+// formatID форматирует ID пользователя для логов
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
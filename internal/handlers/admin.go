@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"net/http"
+	"strings"
+)
+
+// AdminAuth пропускает запрос только с заголовком Authorization: Bearer <token>.
+// Если токен не задан, административные маршруты отключены и отвечают 404.
+func AdminAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				respondWithStatus(w, http.StatusNotFound, &models.ErrorResponse{Error: "admin endpoints are disabled", Code: models.ErrorCodeNotFound})
+				return
+			}
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				logger.Warn("Rejected admin request with missing or invalid token", "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				respondWithStatus(w, http.StatusUnauthorized, &models.ErrorResponse{Error: "invalid admin token", Code: models.ErrorCodeUnauthorized})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenUsageHandler возвращает статистику использования токенов GitHub
+func (h *Handler) TokenUsageHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Info("Processing TokenUsageHandler request")
+
+	usage := h.tokens.Usage()
+	response := models.TokenUsageResponse{Tokens: make([]models.TokenUsage, 0, len(usage))}
+	for _, token := range usage {
+		item := models.TokenUsage{
+			Token:    token.Token,
+			Requests: token.Requests,
+			Limits:   make(map[string]models.RateLimitUsage, len(token.Limits)),
+		}
+		if !token.RetiredUntil.IsZero() {
+			retiredUntil := token.RetiredUntil
+			item.Retired = true
+			item.RetiredUntil = &retiredUntil
+			item.RetireReason = token.RetireReason
+		}
+		for resource, limit := range token.Limits {
+			item.Limits[resource] = models.RateLimitUsage{Limit: limit.Limit, Remaining: limit.Remaining, Reset: limit.Reset}
+		}
+		response.Tokens = append(response.Tokens, item)
+	}
+
+	respondWithJSON(w, response)
+}
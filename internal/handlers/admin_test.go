@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", token: "secret", authorization: "Bearer secret", want: http.StatusOK},
+		{name: "wrong token", token: "secret", authorization: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "missing header", token: "secret", want: http.StatusUnauthorized},
+		{name: "not bearer", token: "secret", authorization: "Basic secret", want: http.StatusUnauthorized},
+		{name: "disabled", token: "", authorization: "Bearer ", want: http.StatusNotFound},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/tokens", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			AdminAuth(tt.token)(next).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
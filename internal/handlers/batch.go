@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gh-checker/internal/config"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"gh-checker/internal/services"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultBatchMaxItems - ограничение размера пакета, если batch.max_items не задан
+const defaultBatchMaxItems = 500
+
+// BatchHandler обрабатывает POST /api/v1/batch: пакет проверок звёзд и подписок.
+// Ошибки отдельных проверок возвращаются в их элементах ответа, статус ответа при этом 200.
+func (h *Handler) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Info("Processing BatchHandler request")
+
+	var req models.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, fmt.Errorf("%w: invalid request body: %v", services.ErrInvalidInput, err), &models.BatchResponse{})
+		return
+	}
+
+	maxItems := config.AppConfig.Batch.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	total := len(req.Stars) + len(req.Follows)
+	if total == 0 || total > maxItems {
+		err := fmt.Errorf("%w: batch must contain between 1 and %d checks, got %d", services.ErrInvalidInput, maxItems, total)
+		respondWithError(w, err, &models.BatchResponse{})
+		return
+	}
+
+	ctx := r.Context()
+	interval := config.AppConfig.FollowerUpdateInterval
+	concurrency := config.AppConfig.Batch.Concurrency
+	response := models.BatchResponse{
+		Stars:   make([]models.StarCheckResponse, len(req.Stars)),
+		Follows: make([]models.SubscribeResponse, len(req.Follows)),
+	}
+
+	// Звёзды: проверяем запросы, находим пользователей и проверяем пакетом
+	var starRefs []userRef
+	for i := range req.Stars {
+		if err := req.Stars[i].Validate(); err != nil {
+			setError(err, &response.Stars[i])
+			continue
+		}
+		starRefs = append(starRefs, userRef{Login: req.Stars[i].Username, ID: req.Stars[i].UserID})
+	}
+	starUsers := resolveUsers(ctx, h.starClient, starRefs, interval, concurrency)
+
+	var starChecks []services.StarCheck
+	var starItems []int
+	for i, item := range req.Stars {
+		if response.Stars[i].Code != "" {
+			continue
+		}
+		resolved := starUsers[userRef{Login: item.Username, ID: item.UserID}]
+		if resolved.err != nil {
+			setError(resolved.err, &response.Stars[i])
+			continue
+		}
+		starChecks = append(starChecks, services.StarCheck{User: resolved.user, Repository: item.Repository})
+		starItems = append(starItems, i)
+	}
+	for j, result := range services.CheckStarredBatch(ctx, h.starClient, starChecks, interval, concurrency) {
+		i := starItems[j]
+		if result.Err != nil {
+			setError(result.Err, &response.Stars[i])
+			continue
+		}
+		response.Stars[i] = models.StarCheckResponse{HasStar: result.Result, Strategy: string(result.Strategy)}
+	}
+
+	// Подписки: то же самое, группировка по пользователю, на которого подписываются
+	var followRefs []userRef
+	for i := range req.Follows {
+		if err := req.Follows[i].Validate(); err != nil {
+			setError(err, &response.Follows[i])
+			continue
+		}
+		followRefs = append(followRefs,
+			userRef{Login: req.Follows[i].Follower, ID: req.Follows[i].FollowerID},
+			userRef{Login: req.Follows[i].Followed, ID: req.Follows[i].FollowedID})
+	}
+	followUsers := resolveUsers(ctx, h.followerClient, followRefs, interval, concurrency)
+
+	var followChecks []services.FollowCheck
+	var followItems []int
+	for i, item := range req.Follows {
+		if response.Follows[i].Code != "" {
+			continue
+		}
+		follower := followUsers[userRef{Login: item.Follower, ID: item.FollowerID}]
+		followed := followUsers[userRef{Login: item.Followed, ID: item.FollowedID}]
+		if follower.err != nil {
+			setError(follower.err, &response.Follows[i])
+			continue
+		}
+		if followed.err != nil {
+			setError(followed.err, &response.Follows[i])
+			continue
+		}
+		followChecks = append(followChecks, services.FollowCheck{Follower: follower.user, User: followed.user})
+		followItems = append(followItems, i)
+	}
+	for j, result := range services.CheckFollowingBatch(ctx, h.followerClient, followChecks, interval, concurrency) {
+		i := followItems[j]
+		if result.Err != nil {
+			setError(result.Err, &response.Follows[i])
+			continue
+		}
+		response.Follows[i] = models.SubscribeResponse{IsFollowing: result.Result, Strategy: string(result.Strategy)}
+	}
+
+	logger.Info(fmt.Sprintf("Processed batch of %d star checks and %d follow checks", len(req.Stars), len(req.Follows)))
+	respondWithJSON(w, response)
+}
+
+// userRef - пользователь из запроса: по ID, если он указан, иначе по логину
+type userRef struct {
+	Login string
+	ID    int64
+}
+
+// resolvedUser - результат поиска пользователя
+type resolvedUser struct {
+	user models.User
+	err  error
+}
+
+// resolveUsers находит всех пользователей пакета, каждого не больше одного раза
+func resolveUsers(ctx context.Context, client services.GitHubClient, refs []userRef, interval time.Duration, concurrency int) map[userRef]resolvedUser {
+	if concurrency < 1 {
+		concurrency = services.DefaultBatchConcurrency
+	}
+
+	unique := make(map[userRef]struct{}, len(refs))
+	for _, ref := range refs {
+		if ref.ID != 0 {
+			// При указанном ID логин не используется
+			ref.Login = ""
+		}
+		unique[ref] = struct{}{}
+	}
+
+	results := make(map[userRef]resolvedUser, len(unique))
+	var mu sync.Mutex
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for ref := range unique {
+		g.Go(func() error {
+			user, err := services.ResolveUser(ctx, client, ref.Login, ref.ID, interval)
+			mu.Lock()
+			results[ref] = resolvedUser{user: user, err: err}
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+
+	// Ссылки с ID и логином указывают на тот же результат, что и ссылка только по ID
+	for _, ref := range refs {
+		if ref.ID != 0 && ref.Login != "" {
+			results[ref] = results[userRef{ID: ref.ID}]
+		}
+	}
+	return results
+}
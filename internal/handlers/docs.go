@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// openAPISpec - спецификация OpenAPI 3 всех маршрутов сервиса
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerUIAssets - файлы swagger-ui-dist 4.15.5, встроенные в бинарник, чтобы /docs работал без доступа к CDN
+//
+//go:embed swagger-ui
+var swaggerUIAssets embed.FS
+
+// swaggerUIPage - страница Swagger UI, которая загружает спецификацию с /openapi.json.
+// Скрипты подключаются файлами, а не встроенным кодом, чтобы страница работала со строгой CSP.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>gh-checker API</title>
+  <link rel="stylesheet" href="/docs/assets/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/assets/swagger-ui-bundle.js"></script>
+  <script src="/docs/assets/swagger-initializer.js"></script>
+</body>
+</html>
+`
+
+// undocumentedRoutes - маршруты, которые намеренно не описаны в спецификации
+var undocumentedRoutes = map[string]bool{
+	"GET /docs/assets/*": true,
+}
+
+// OpenAPIHandler отдаёт спецификацию OpenAPI
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// DocsHandler отдаёт страницу Swagger UI
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+// SwaggerUIAssetsHandler отдаёт встроенные файлы Swagger UI по адресам /docs/assets/...
+func SwaggerUIAssetsHandler() http.Handler {
+	assets, err := fs.Sub(swaggerUIAssets, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/docs/assets/", http.FileServer(http.FS(assets)))
+}
+
+// CheckSpecRoutes сверяет маршруты роутера со спецификацией OpenAPI.
+// Возвращает ошибку со списком маршрутов, которые есть только в роутере или только в спецификации.
+func CheckSpecRoutes(routes chi.Routes) error {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("parse openapi spec: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := make(map[string]bool)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if undocumentedRoutes[method+" "+route] {
+			return nil
+		}
+		// chi добавляет /* к маршрутам внутри Route, если у подроутера есть корневой обработчик
+		route = strings.TrimSuffix(route, "/*")
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk routes: %w", err)
+	}
+
+	var drift []string
+	for route := range registered {
+		if !documented[route] {
+			drift = append(drift, "not in spec: "+route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			drift = append(drift, "not registered: "+route)
+		}
+	}
+	if len(drift) > 0 {
+		sort.Strings(drift)
+		return fmt.Errorf("routes drifted from openapi spec: %s", strings.Join(drift, "; "))
+	}
+	return nil
+}
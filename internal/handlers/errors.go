@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"gh-checker/internal/services"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// errorStatus определяет HTTP-статус и код ошибки по доменной ошибке сервиса
+func errorStatus(err error) (int, string) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, models.ErrorCodeInvalidInput
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest, models.ErrorCodeInvalidInput
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound, models.ErrorCodeUserNotFound
+	case errors.Is(err, services.ErrRepoNotFound):
+		return http.StatusNotFound, models.ErrorCodeRepoNotFound
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound, models.ErrorCodeNotFound
+	case errors.Is(err, services.ErrRateLimited):
+		return http.StatusTooManyRequests, models.ErrorCodeRateLimited
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized, models.ErrorCodeUnauthorized
+	case errors.Is(err, services.ErrForbidden):
+		// 403 без признаков лимита: у токена нет доступа к ресурсу, а не сбой GitHub
+		return http.StatusForbidden, models.ErrorCodeForbidden
+	case errors.Is(err, services.ErrUpstreamUnavailable):
+		return http.StatusBadGateway, models.ErrorCodeUpstreamUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, models.ErrorCodeTimeout
+	}
+
+	// Прочие ответы GitHub с ошибкой - тоже сбой на стороне GitHub
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) {
+		return http.StatusBadGateway, models.ErrorCodeUpstreamUnavailable
+	}
+	return http.StatusInternalServerError, models.ErrorCodeInternal
+}
+
+// setError заполняет в response поля error, code и fields и возвращает HTTP-статус ошибки
+func setError(err error, response models.ErrorSetter) int {
+	status, code := errorStatus(err)
+	var fields []models.FieldError
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+	}
+	response.SetError(err.Error(), code, fields)
+	return status
+}
+
+// respondWithError отвечает с ошибкой и логирует её.
+// Статус и код определяются типом ошибки, тело - response с заполненными полями error и code.
+func respondWithError(w http.ResponseWriter, err error, response models.ErrorSetter) {
+	logger.Error("Responding with error", err)
+
+	status := setError(err, response)
+
+	var rlErr *services.RateLimitError
+	if errors.As(err, &rlErr) {
+		retryAfter := int(math.Ceil(rlErr.RetryAfter().Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	respondWithStatus(w, status, response)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh-checker/internal/models"
+	"gh-checker/internal/services"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestErrorStatus(t *testing.T) {
+	apiErr := func(status int, url string) error {
+		return &services.APIError{StatusCode: status, URL: url, Message: http.StatusText(status)}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "validation", err: &models.ValidationError{}, wantStatus: http.StatusBadRequest, wantCode: models.ErrorCodeInvalidInput},
+		{name: "user not found", err: &services.APIError{StatusCode: http.StatusNotFound, Resource: "user"}, wantStatus: http.StatusNotFound, wantCode: models.ErrorCodeUserNotFound},
+		{name: "unauthorized", err: apiErr(http.StatusUnauthorized, "https://api.github.com/user"), wantStatus: http.StatusUnauthorized, wantCode: models.ErrorCodeUnauthorized},
+		{name: "forbidden", err: apiErr(http.StatusForbidden, "https://api.github.com/repos/owner/private"), wantStatus: http.StatusForbidden, wantCode: models.ErrorCodeForbidden},
+		{name: "wrapped forbidden", err: fmt.Errorf("check: %w", apiErr(http.StatusForbidden, "https://api.github.com/graphql")), wantStatus: http.StatusForbidden, wantCode: models.ErrorCodeForbidden},
+		{name: "rate limit", err: &services.RateLimitError{Reset: time.Now().Add(time.Minute)}, wantStatus: http.StatusTooManyRequests, wantCode: models.ErrorCodeRateLimited},
+		{name: "server error", err: apiErr(http.StatusBadGateway, "https://api.github.com/users/octocat"), wantStatus: http.StatusBadGateway, wantCode: models.ErrorCodeUpstreamUnavailable},
+		{name: "other client error", err: apiErr(http.StatusUnprocessableEntity, "https://api.github.com/graphql"), wantStatus: http.StatusBadGateway, wantCode: models.ErrorCodeUpstreamUnavailable},
+		{name: "timeout", err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout, wantCode: models.ErrorCodeTimeout},
+		{name: "internal", err: errors.New("disk full"), wantStatus: http.StatusInternalServerError, wantCode: models.ErrorCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorStatus(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("errorStatus(%v) = %d %s, want %d %s", tt.err, status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import "gh-checker/internal/services"
+
+// Handler объединяет HTTP-обработчики и их зависимости
+type Handler struct {
+	followerClient services.GitHubClient // Клиент для проверок подписок
+	starClient     services.GitHubClient // Клиент для проверок звёзд
+	tokens         *services.TokenPool
+}
+
+// NewHandler создаёт обработчики, работающие через переданные клиенты GitHub.
+// Клиенты могут совпадать или использовать разные бэкенды (REST, GraphQL).
+func NewHandler(followerClient, starClient services.GitHubClient, tokens *services.TokenPool) *Handler {
+	return &Handler{followerClient: followerClient, starClient: starClient, tokens: tokens}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh-checker/internal/config"
+	"gh-checker/internal/database"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"gh-checker/internal/services"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// readinessTimeout - сколько ждать ответа зависимостей при проверке готовности
+const readinessTimeout = 5 * time.Second
+
+// HealthHandler отвечает, что процесс жив. Зависимости не проверяются.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, models.HealthResponse{Status: models.StatusOK})
+}
+
+// ReadyHandler проверяет базу данных и GitHub: токен должен приниматься, а остаток квоты быть не ниже server.ready_min_quota.
+// Если какая-то зависимость недоступна, отвечает 503 с результатом каждой проверки.
+func (h *Handler) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) models.CheckStatus{
+		"database": checkDatabase,
+	}
+	// Клиенты проверок могут совпадать - тогда проверяем один раз
+	if h.followerClient == h.starClient {
+		checks["github"] = githubCheck(h.followerClient)
+	} else {
+		checks["github_followers"] = githubCheck(h.followerClient)
+		checks["github_stars"] = githubCheck(h.starClient)
+	}
+
+	response := models.ReadinessResponse{Status: models.StatusReady, Checks: make(map[string]models.CheckStatus, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := check(ctx)
+			mu.Lock()
+			response.Checks[name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for name, check := range response.Checks {
+		if check.Status != models.StatusOK {
+			logger.Warn("Readiness check failed", "check", name, "error", check.Error)
+			response.Status = models.StatusNotReady
+			status = http.StatusServiceUnavailable
+		}
+	}
+	respondWithStatus(w, status, response)
+}
+
+// checkDatabase проверяет соединение с базой данных
+func checkDatabase(ctx context.Context) models.CheckStatus {
+	if err := database.Ping(ctx); err != nil {
+		return models.CheckStatus{Status: models.StatusFail, Error: err.Error()}
+	}
+	return models.CheckStatus{Status: models.StatusOK}
+}
+
+// githubCheck проверяет каждый токен пула: GitHub должен принимать хотя бы один,
+// а суммарный остаток квоты принятых токенов быть не ниже порога
+func githubCheck(client services.GitHubClient) func(context.Context) models.CheckStatus {
+	return func(ctx context.Context) models.CheckStatus {
+		tokens, err := client.RateLimit(ctx)
+		if errors.Is(err, services.ErrRateLimitDisabled) {
+			return models.CheckStatus{Status: models.StatusOK}
+		}
+		if err != nil {
+			return models.CheckStatus{Status: models.StatusFail, Error: err.Error()}
+		}
+
+		check := models.CheckStatus{Status: models.StatusOK, Tokens: make([]models.CheckStatus, 0, len(tokens))}
+		limit, remaining, accepted := 0, 0, 0
+		var reset time.Time
+		for _, token := range tokens {
+			if token.Err != nil {
+				check.Tokens = append(check.Tokens, models.CheckStatus{Token: token.Token, Status: models.StatusFail, Error: token.Err.Error()})
+				continue
+			}
+			check.Tokens = append(check.Tokens, models.CheckStatus{Token: token.Token, Status: models.StatusOK, Limit: &token.Limit, Remaining: &token.Remaining, Reset: &token.Reset})
+			accepted++
+			limit += token.Limit
+			remaining += token.Remaining
+			if reset.IsZero() || token.Reset.Before(reset) {
+				reset = token.Reset
+			}
+		}
+
+		if accepted == 0 {
+			check.Status = models.StatusFail
+			check.Error = "no GitHub token is accepted"
+			return check
+		}
+		check.Limit, check.Remaining, check.Reset = &limit, &remaining, &reset
+		if minQuota := config.AppConfig.Server.ReadyMinQuota; remaining < minQuota {
+			check.Status = models.StatusFail
+			check.Error = fmt.Sprintf("remaining quota %d is below %d", remaining, minQuota)
+		}
+		return check
+	}
+}
+
+// VersionHandler возвращает сведения о сборке из debug.ReadBuildInfo
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		respondWithError(w, errors.New("build info is not available"), &models.ErrorResponse{})
+		return
+	}
+
+	response := models.VersionResponse{Version: info.Main.Version, GoVersion: info.GoVersion}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			response.Revision = setting.Value
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				response.Time = &t
+			}
+		case "vcs.modified":
+			response.Modified = setting.Value == "true"
+		}
+	}
+	respondWithJSON(w, response)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"gh-checker/internal/config"
+	"gh-checker/internal/models"
+	"gh-checker/internal/services"
+	"testing"
+	"time"
+)
+
+// fakeRateLimitClient возвращает заданное состояние лимитов токенов
+type fakeRateLimitClient struct {
+	services.GitHubClient
+	tokens []services.TokenRateLimit
+	err    error
+}
+
+func (c fakeRateLimitClient) RateLimit(ctx context.Context) ([]services.TokenRateLimit, error) {
+	return c.tokens, c.err
+}
+
+func TestGithubCheck(t *testing.T) {
+	config.AppConfig.Server.ReadyMinQuota = 100
+	reset := time.Now().Add(time.Hour)
+	valid := func(name string, remaining int) services.TokenRateLimit {
+		return services.TokenRateLimit{Token: name, RateLimitUsage: services.RateLimitUsage{Limit: 5000, Remaining: remaining, Reset: reset}}
+	}
+	revoked := services.TokenRateLimit{Token: "****-bad", Err: services.ErrUnauthorized}
+
+	tests := []struct {
+		name          string
+		client        fakeRateLimitClient
+		wantStatus    string
+		wantRemaining int
+		wantTokens    int
+	}{
+		{name: "all tokens accepted", client: fakeRateLimitClient{tokens: []services.TokenRateLimit{valid("****-one", 60), valid("****-two", 70)}}, wantStatus: models.StatusOK, wantRemaining: 130, wantTokens: 2},
+		{name: "revoked token is reported", client: fakeRateLimitClient{tokens: []services.TokenRateLimit{valid("****-one", 4000), revoked}}, wantStatus: models.StatusOK, wantRemaining: 4000, wantTokens: 2},
+		{name: "no token accepted", client: fakeRateLimitClient{tokens: []services.TokenRateLimit{revoked}}, wantStatus: models.StatusFail, wantTokens: 1},
+		{name: "quota below threshold", client: fakeRateLimitClient{tokens: []services.TokenRateLimit{valid("****-one", 40), valid("****-two", 50)}}, wantStatus: models.StatusFail, wantRemaining: 90, wantTokens: 2},
+		{name: "rate limit disabled", client: fakeRateLimitClient{err: services.ErrRateLimitDisabled}, wantStatus: models.StatusOK},
+		{name: "github unavailable", client: fakeRateLimitClient{err: errors.New("connection refused")}, wantStatus: models.StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := githubCheck(tt.client)(context.Background())
+			if check.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s (error %q)", check.Status, tt.wantStatus, check.Error)
+			}
+			if len(check.Tokens) != tt.wantTokens {
+				t.Errorf("got %d token checks, want %d", len(check.Tokens), tt.wantTokens)
+			}
+			if tt.wantRemaining > 0 && (check.Remaining == nil || *check.Remaining != tt.wantRemaining) {
+				t.Errorf("remaining = %v, want %d", check.Remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
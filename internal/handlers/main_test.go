@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"gh-checker/internal/lib/logger"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.InitializeLogger(logger.LogConfig{FilePath: os.DevNull}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
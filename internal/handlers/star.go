@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"gh-checker/internal/config"
 	"gh-checker/internal/lib/logger"
 	"gh-checker/internal/models"
@@ -11,7 +13,13 @@ import (
 
 // respondWithJSON отвечает клиенту с JSON-ответом и заголовком Content-Type
 func respondWithJSON(w http.ResponseWriter, data interface{}) {
+	respondWithStatus(w, http.StatusOK, data)
+}
+
+// respondWithStatus отвечает клиенту JSON-ответом с указанным статусом
+func respondWithStatus(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		logger.Error("Error encoding JSON response", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -19,29 +27,47 @@ func respondWithJSON(w http.ResponseWriter, data interface{}) {
 }
 
 // StarCheckHandler обрабатывает запрос на проверку, поставил ли пользователь звезду на репозиторий
-func StarCheckHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) StarCheckHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Info("Processing StarCheckHandler request")
 
 	var req models.StarCheckRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		logger.Error("Invalid request body", err)
+		respondWithError(w, fmt.Errorf("%w: invalid request body: %v", services.ErrInvalidInput, err), &models.StarCheckResponse{})
 		return
 	}
+	if err := req.Validate(); err != nil {
+		respondWithError(w, err, &models.StarCheckResponse{})
+		return
+	}
+	setSuccessorLink(w, stargazerCheckURL(req))
 
-	logger.Info("Received request to check if " + req.Username + " starred repository " + req.Repository)
-
-	hasStar, err := services.UpdateStars(req.Username, req.Repository, config.AppConfig.FollowerUpdateInterval)
+	response, err := h.checkStar(r.Context(), req)
 	if err != nil {
-		logger.Error("Error while updating stars", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, err, &models.StarCheckResponse{})
 		return
 	}
 
-	response := models.StarCheckResponse{HasStar: hasStar}
-
 	// Устанавливаем заголовок Content-Type и отвечаем клиенту
 	respondWithJSON(w, response)
 
-	logger.Info("Successfully responded to star check for user " + req.Username + " on repository " + req.Repository)
+	logger.Info("Successfully responded to star check on repository " + req.Repository)
+}
+
+// checkStar проверяет звезду для уже провалидированного запроса
+func (h *Handler) checkStar(ctx context.Context, req models.StarCheckRequest) (models.StarCheckResponse, error) {
+	interval := config.AppConfig.FollowerUpdateInterval
+	user, err := services.ResolveUser(ctx, h.starClient, req.Username, req.UserID, interval)
+	if err != nil {
+		return models.StarCheckResponse{}, err
+	}
+
+	logger.Info("Received request to check if " + user.Login + " starred repository " + req.Repository)
+
+	result, err := services.CheckStarred(ctx, h.starClient, user, req.Repository, interval)
+	if err != nil {
+		logger.Error("Error while updating stars", err)
+		return models.StarCheckResponse{}, err
+	}
+
+	return models.StarCheckResponse{HasStar: result.Result, Strategy: string(result.Strategy)}, nil
 }
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"gh-checker/internal/config"
 	"gh-checker/internal/lib/logger" // Импортируем твой логгер
 	"gh-checker/internal/models"
@@ -9,60 +11,58 @@ import (
 	"net/http"
 )
 
-// respondWithError отвечает с ошибкой и логирует её
-func respondWithError(w http.ResponseWriter, err error) {
-	logger.Error("Responding with error", err)
-	w.WriteHeader(http.StatusInternalServerError)
-	if encodeErr := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); encodeErr != nil {
-		logger.Error("Error while encoding error response", encodeErr) // Логируем ошибку кодирования
-	}
-}
-
 // SubscribeHandler обрабатывает запрос на проверку подписчиков
-func SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Info("Processing SubscribeHandler request")
 	var req models.SubscribeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		logger.Error("Invalid request body", err)
+		respondWithError(w, fmt.Errorf("%w: invalid request body: %v", services.ErrInvalidInput, err), &models.SubscribeResponse{})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondWithError(w, err, &models.SubscribeResponse{})
 		return
 	}
 	logger.Info("Request body successfully decoded")
+	setSuccessorLink(w, followerCheckURL(req))
 
-	logger.Info("Received request to check if " + req.Follower + " is following " + req.Followed)
-
-	logger.Info("Calling UpdateFollowers service for user " + req.Followed)
-	followers, updated, err := services.UpdateFollowers(req.Followed, config.AppConfig.FollowerUpdateInterval)
+	response, err := h.checkFollowing(r.Context(), req)
 	if err != nil {
-		logger.Error("Error while updating followers", err)
-		respondWithError(w, err)
+		respondWithError(w, err, &models.SubscribeResponse{})
 		return
 	}
-	logger.Info("UpdateFollowers service call succeeded")
 
-	isFollowing := false
-	logger.Info("Checking if " + req.Follower + " is in the list of followers")
-	for _, follower := range followers {
-		if follower == req.Follower {
-			isFollowing = true
-			logger.Info(req.Follower + " is following " + req.Followed)
-			break
-		}
+	// Устанавливаем заголовок Content-Type и отвечаем клиенту
+	respondWithJSON(w, response)
+
+	logger.Info("Response successfully sent to the client")
+}
+
+// checkFollowing проверяет подписку для уже провалидированного запроса
+func (h *Handler) checkFollowing(ctx context.Context, req models.SubscribeRequest) (models.SubscribeResponse, error) {
+	interval := config.AppConfig.FollowerUpdateInterval
+	follower, err := services.ResolveUser(ctx, h.followerClient, req.Follower, req.FollowerID, interval)
+	if err != nil {
+		return models.SubscribeResponse{}, err
 	}
-	if !isFollowing {
-		logger.Info(req.Follower + " is not following " + req.Followed)
+	followed, err := services.ResolveUser(ctx, h.followerClient, req.Followed, req.FollowedID, interval)
+	if err != nil {
+		return models.SubscribeResponse{}, err
 	}
 
-	if updated {
-		logger.Info("Followers list for " + req.Followed + " was updated from GitHub API")
-	} else {
-		logger.Info("Using cached followers data for " + req.Followed)
-	}
+	logger.Info("Received request to check if " + follower.Login + " is following " + followed.Login)
 
-	response := models.SubscribeResponse{IsFollowing: isFollowing}
+	result, err := services.CheckFollowing(ctx, h.followerClient, follower, followed, interval)
+	if err != nil {
+		logger.Error("Error while checking follow", err)
+		return models.SubscribeResponse{}, err
+	}
 
-	// Устанавливаем заголовок Content-Type и отвечаем клиенту
-	respondWithJSON(w, response)
+	if result.Result {
+		logger.Info(follower.Login+" is following "+followed.Login, "strategy", result.Strategy)
+	} else {
+		logger.Info(follower.Login+" is not following "+followed.Login, "strategy", result.Strategy)
+	}
 
-	logger.Info("Response successfully sent to the client")
+	return models.SubscribeResponse{IsFollowing: result.Result, Strategy: string(result.Strategy)}, nil
 }
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"gh-checker/internal/config"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// unusedPathSegment - сегмент пути вместо логина пользователя, заданного ID в параметре запроса
+const unusedPathSegment = "-"
+
+// FollowerCheckHandler обрабатывает GET /api/v1/users/{user}/followers/{follower}.
+// Параметры userId и followerId задают пользователей по ID, тогда соответствующий сегмент пути не используется.
+func (h *Handler) FollowerCheckHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Info("Processing FollowerCheckHandler request")
+
+	var req models.SubscribeRequest
+	var validationErr models.ValidationError
+	req.Followed, req.FollowedID = userFromPath(r, "user", "userId", &validationErr)
+	req.Follower, req.FollowerID = userFromPath(r, "follower", "followerId", &validationErr)
+	if len(validationErr.Fields) > 0 {
+		respondWithError(w, &validationErr, &models.SubscribeResponse{})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondWithError(w, err, &models.SubscribeResponse{})
+		return
+	}
+
+	response, err := h.checkFollowing(r.Context(), req)
+	if err != nil {
+		respondWithError(w, err, &models.SubscribeResponse{})
+		return
+	}
+
+	setCacheControl(w)
+	respondWithJSON(w, response)
+}
+
+// StargazerCheckHandler обрабатывает GET /api/v1/repos/{owner}/{repo}/stargazers/{user}.
+// Параметр userId задаёт пользователя по ID, тогда сегмент {user} не используется.
+func (h *Handler) StargazerCheckHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Info("Processing StargazerCheckHandler request")
+
+	req := models.StarCheckRequest{Repository: chi.URLParam(r, "owner") + "/" + chi.URLParam(r, "repo")}
+	var validationErr models.ValidationError
+	req.Username, req.UserID = userFromPath(r, "user", "userId", &validationErr)
+	if len(validationErr.Fields) > 0 {
+		respondWithError(w, &validationErr, &models.StarCheckResponse{})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondWithError(w, err, &models.StarCheckResponse{})
+		return
+	}
+
+	response, err := h.checkStar(r.Context(), req)
+	if err != nil {
+		respondWithError(w, err, &models.StarCheckResponse{})
+		return
+	}
+
+	setCacheControl(w)
+	respondWithJSON(w, response)
+}
+
+// userFromPath возвращает логин из сегмента пути param или ID из параметра запроса idParam.
+// Если ID задан, логин из пути не используется. Некорректный ID добавляется в validationErr.
+func userFromPath(r *http.Request, param, idParam string, validationErr *models.ValidationError) (string, int64) {
+	rawID := r.URL.Query().Get(idParam)
+	if rawID == "" {
+		return chi.URLParam(r, param), 0
+	}
+
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		validationErr.Fields = append(validationErr.Fields, models.FieldError{Field: idParam, Message: "must be a positive number"})
+		return "", 0
+	}
+	return "", id
+}
+
+// userPathSegment возвращает сегмент пути для пользователя: логин или "-", если пользователь задан ID
+func userPathSegment(login string, id int64, idParam string, query url.Values) string {
+	if id != 0 {
+		query.Set(idParam, strconv.FormatInt(id, 10))
+		return unusedPathSegment
+	}
+	return url.PathEscape(login)
+}
+
+// followerCheckURL возвращает адрес GET-маршрута, равнозначного запросу к /api/subscribe
+func followerCheckURL(req models.SubscribeRequest) string {
+	query := url.Values{}
+	user := userPathSegment(req.Followed, req.FollowedID, "userId", query)
+	follower := userPathSegment(req.Follower, req.FollowerID, "followerId", query)
+	return withQuery("/api/v1/users/"+user+"/followers/"+follower, query)
+}
+
+// stargazerCheckURL возвращает адрес GET-маршрута, равнозначного запросу к /check-star
+func stargazerCheckURL(req models.StarCheckRequest) string {
+	query := url.Values{}
+	user := userPathSegment(req.Username, req.UserID, "userId", query)
+	owner, repo, _ := strings.Cut(req.Repository, "/")
+	return withQuery("/api/v1/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/stargazers/"+user, query)
+}
+
+// withQuery добавляет к пути параметры запроса, если они есть
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// setSuccessorLink указывает в заголовке Link маршрут, который заменяет устаревший
+func setSuccessorLink(w http.ResponseWriter, successor string) {
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+}
+
+// setCacheControl разрешает кэшировать успешный ответ на время, пока сервис сам считает свой кэш свежим
+func setCacheControl(w http.ResponseWriter) {
+	maxAge := int(config.AppConfig.FollowerUpdateInterval.Seconds())
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+}
+
+// legacyRoutesDeprecatedAt - когда POST-маршруты заменены маршрутами /api/v1
+var legacyRoutesDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// Deprecated помечает маршрут устаревшим заголовком Deprecation в формате RFC 9745: @<unix-время>.
+// Ссылку на заменяющий маршрут обработчик добавляет сам: она зависит от тела запроса.
+func Deprecated(next http.Handler) http.Handler {
+	deprecation := "@" + strconv.FormatInt(legacyRoutesDeprecatedAt.Unix(), 10)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"gh-checker/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSuccessorURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "follower by login",
+			got:  followerCheckURL(models.SubscribeRequest{Follower: "alice", Followed: "bob"}),
+			want: "/api/v1/users/bob/followers/alice",
+		},
+		{
+			name: "follower by id",
+			got:  followerCheckURL(models.SubscribeRequest{Follower: "alice", FollowedID: 42}),
+			want: "/api/v1/users/-/followers/alice?userId=42",
+		},
+		{
+			name: "both by id",
+			got:  followerCheckURL(models.SubscribeRequest{FollowerID: 7, FollowedID: 42}),
+			want: "/api/v1/users/-/followers/-?followerId=7&userId=42",
+		},
+		{
+			name: "stargazer by login",
+			got:  stargazerCheckURL(models.StarCheckRequest{Username: "alice", Repository: "owner/repo.js"}),
+			want: "/api/v1/repos/owner/repo.js/stargazers/alice",
+		},
+		{
+			name: "stargazer by id",
+			got:  stargazerCheckURL(models.StarCheckRequest{UserID: 7, Repository: "owner/repo"}),
+			want: "/api/v1/repos/owner/repo/stargazers/-?userId=7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserFromPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantLogin string
+		wantID    int64
+		wantErr   bool
+	}{
+		{name: "login", target: "/users/octocat", wantLogin: "octocat"},
+		{name: "id overrides path", target: "/users/-?userId=583231", wantID: 583231},
+		{name: "invalid id", target: "/users/-?userId=abc", wantErr: true},
+		{name: "negative id", target: "/users/-?userId=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var login string
+			var id int64
+			var validationErr models.ValidationError
+			r := chi.NewRouter()
+			r.Get("/users/{user}", func(w http.ResponseWriter, r *http.Request) {
+				login, id = userFromPath(r, "user", "userId", &validationErr)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if got := len(validationErr.Fields) > 0; got != tt.wantErr {
+				t.Fatalf("validation errors %v, wantErr %v", validationErr.Fields, tt.wantErr)
+			}
+			if login != tt.wantLogin || id != tt.wantID {
+				t.Errorf("got (%q, %d), want (%q, %d)", login, id, tt.wantLogin, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	handler := Deprecated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/check-star", nil))
+
+	// RFC 9745: Deprecation - структурированная дата, @ и Unix-время
+	if got, want := rec.Header().Get("Deprecation"), "@1792281600"; got != want {
+		t.Errorf("Deprecation = %q, want %q", got, want)
+	}
+}
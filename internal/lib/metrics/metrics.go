@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gh_checker"
+
+var (
+	// HTTPRequests - запросы к сервису по маршруту, методу и статусу
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled by the service.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration - время обработки запросов к сервису
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent handling HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// GitHubRequests - попытки запросов к GitHub по эндпоинту, результату и номеру попытки
+	GitHubRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_requests_total",
+		Help:      "GitHub API request attempts by endpoint, status and attempt number.",
+	}, []string{"endpoint", "status", "attempt"})
+
+	// GitHubRequestDuration - время одной попытки запроса к GitHub
+	GitHubRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "github_request_duration_seconds",
+		Help:      "Duration of a single GitHub API request attempt.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// GitHubPages - загруженные страницы списков GitHub
+	GitHubPages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_pages_fetched_total",
+		Help:      "Pages of GitHub list endpoints fetched.",
+	}, []string{"endpoint"})
+
+	// GitHubRateLimitRemaining - последний известный остаток квоты каждого токена
+	GitHubRateLimitRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining GitHub API quota per token and resource.",
+	}, []string{"token", "resource"})
+
+	// GitHubGraphQLCost - стоимость запросов GraphQL в очках лимита по токенам
+	GitHubGraphQLCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_graphql_cost_total",
+		Help:      "GitHub GraphQL rate limit points spent per token.",
+	}, []string{"token"})
+
+	// CacheLookups - обращения к локальному кэшу подписчиков и звёзд: hit - ответ из кэша,
+	// refresh - кэш устарел и обновлён из GitHub, miss - кэш устарел и ответ получен без его обновления
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Follower and star cache lookups by result (hit, refresh, miss).",
+	}, []string{"cache", "result"})
+
+	// CoalescedCalls - вызовы, которые дождались уже идущего обновления того же ресурса вместо своего запроса к GitHub
+	CoalescedCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_calls_total",
+		Help:      "Calls that shared an in-flight GitHub refresh of the same resource instead of starting their own.",
+	}, []string{"resource"})
+
+	// DBQueryDuration - время выполнения функций пакета database
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "SQLite query latency per database function.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"function"})
+)
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware считает запросы к сервису. Маршрут берётся из шаблона chi, чтобы не плодить метки на каждый логин.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		HTTPRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveDB засекает время выполнения функции базы данных: defer metrics.ObserveDB("GetFollowers")()
+func ObserveDB(function string) func() {
+	start := time.Now()
+	return func() {
+		DBQueryDuration.WithLabelValues(function).Observe(time.Since(start).Seconds())
+	}
+}
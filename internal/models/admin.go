@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type TokenUsage struct {
+	Token        string                    `json:"token"`                  // Замаскированный токен
+	Requests     int64                     `json:"requests"`               // Сколько запросов выполнено с токеном
+	Retired      bool                      `json:"retired"`                // Токен временно выведен из ротации
+	RetiredUntil *time.Time                `json:"retiredUntil,omitempty"` // До какого времени токен выведен из ротации
+	RetireReason string                    `json:"retireReason,omitempty"`
+	Limits       map[string]RateLimitUsage `json:"limits"` // Лимиты по ресурсам GitHub (core, graphql)
+}
+
+type RateLimitUsage struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+type TokenUsageResponse struct {
+	Tokens []TokenUsage `json:"tokens"`
+}
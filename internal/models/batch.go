@@ -0,0 +1,24 @@
+package models
+
+// BatchRequest - пакет проверок звёзд и подписок
+type BatchRequest struct {
+	Stars   []StarCheckRequest `json:"stars"`
+	Follows []SubscribeRequest `json:"follows"`
+}
+
+// BatchResponse содержит результаты в том же порядке, что и проверки в запросе.
+// Ошибка отдельной проверки записывается в её элемент, поля error и code верхнего уровня - ошибка всего запроса.
+type BatchResponse struct {
+	Stars   []StarCheckResponse `json:"stars"`
+	Follows []SubscribeResponse `json:"follows"`
+	Error   string              `json:"error,omitempty"`
+	Code    string              `json:"code,omitempty"`
+	Fields  []FieldError        `json:"fields,omitempty"`
+}
+
+// SetError записывает ошибку в ответ
+func (r *BatchResponse) SetError(message, code string, fields []FieldError) {
+	r.Error = message
+	r.Code = code
+	r.Fields = fields
+}
@@ -0,0 +1,33 @@
+package models
+
+// Машиночитаемые коды ошибок в поле code ответов
+const (
+	ErrorCodeInvalidInput        = "invalid_input"
+	ErrorCodeNotFound            = "not_found"
+	ErrorCodeUserNotFound        = "user_not_found"
+	ErrorCodeRepoNotFound        = "repo_not_found"
+	ErrorCodeRateLimited         = "rate_limited"
+	ErrorCodeUnauthorized        = "unauthorized"
+	ErrorCodeForbidden           = "forbidden"
+	ErrorCodeUpstreamUnavailable = "upstream_unavailable"
+	ErrorCodeTimeout             = "timeout"
+	ErrorCodeInternal            = "internal_error"
+)
+
+// ErrorSetter реализуют ответы, в которые можно записать ошибку
+type ErrorSetter interface {
+	SetError(message, code string, fields []FieldError)
+}
+
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Code   string       `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"` // Некорректные поля запроса
+}
+
+// SetError записывает ошибку в ответ
+func (r *ErrorResponse) SetError(message, code string, fields []FieldError) {
+	r.Error = message
+	r.Code = code
+	r.Fields = fields
+}
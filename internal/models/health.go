@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Состояния в ответах /healthz и /readyz
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status"` // ready или not_ready
+	Checks map[string]CheckStatus `json:"checks"` // Результат проверки каждой зависимости
+}
+
+type CheckStatus struct {
+	Token     string        `json:"token,omitempty"` // Маскированный токен GitHub, к которому относится проверка
+	Status    string        `json:"status"`          // ok или fail
+	Error     string        `json:"error,omitempty"`
+	Limit     *int          `json:"limit,omitempty"`     // Лимит запросов GitHub
+	Remaining *int          `json:"remaining,omitempty"` // Остаток квоты GitHub
+	Reset     *time.Time    `json:"reset,omitempty"`     // Время сброса квоты GitHub
+	Tokens    []CheckStatus `json:"tokens,omitempty"`    // Проверка каждого токена пула GitHub
+}
+
+type VersionResponse struct {
+	Version   string     `json:"version"`            // Версия модуля, (devel) для локальной сборки
+	GoVersion string     `json:"goVersion"`          // Версия Go, которой собран бинарник
+	Revision  string     `json:"revision,omitempty"` // Коммит, из которого собран бинарник
+	Time      *time.Time `json:"time,omitempty"`     // Время коммита
+	Modified  bool       `json:"modified"`           // Сборка из рабочей копии с незакоммиченными изменениями
+}
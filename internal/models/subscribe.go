@@ -1,21 +1,44 @@
 package models
 
 type SubscribeRequest struct {
-	Follower string `json:"follower"`
-	Followed string `json:"followed"`
+	Follower   string `json:"follower"`
+	FollowerID int64  `json:"followerId,omitempty"` // ID подписчика, используется вместо логина
+	Followed   string `json:"followed"`
+	FollowedID int64  `json:"followedId,omitempty"` // ID пользователя, используется вместо логина
 }
 
 type SubscribeResponse struct {
-	IsFollowing bool   `json:"isFollowing"`
-	Error       string `json:"error,omitempty"`
+	IsFollowing bool         `json:"isFollowing"`
+	Strategy    string       `json:"strategy,omitempty"` // Способ проверки: cache, direct
+	Error       string       `json:"error,omitempty"`
+	Code        string       `json:"code,omitempty"`   // Машиночитаемый код ошибки
+	Fields      []FieldError `json:"fields,omitempty"` // Некорректные поля запроса
+}
+
+// SetError записывает ошибку в ответ
+func (r *SubscribeResponse) SetError(message, code string, fields []FieldError) {
+	r.Error = message
+	r.Code = code
+	r.Fields = fields
 }
 
 type StarCheckRequest struct {
-	Username   string `json:"username"`   // Пользователь, который ставит звезду
-	Repository string `json:"repository"` // Репозиторий, на который ставится звезда
+	Username   string `json:"username"`         // Пользователь, который ставит звезду
+	UserID     int64  `json:"userId,omitempty"` // ID пользователя, используется вместо логина
+	Repository string `json:"repository"`       // Репозиторий, на который ставится звезда
 }
 
 type StarCheckResponse struct {
-	HasStar bool   `json:"hasStar"` // Флаг: есть ли звезда на репозитории
-	Error   string `json:"error,omitempty"`
+	HasStar  bool         `json:"hasStar"`            // Флаг: есть ли звезда на репозитории
+	Strategy string       `json:"strategy,omitempty"` // Способ проверки: cache, scan_stargazers, scan_starred
+	Error    string       `json:"error,omitempty"`
+	Code     string       `json:"code,omitempty"`   // Машиночитаемый код ошибки
+	Fields   []FieldError `json:"fields,omitempty"` // Некорректные поля запроса
+}
+
+// SetError записывает ошибку в ответ
+func (r *StarCheckResponse) SetError(message, code string, fields []FieldError) {
+	r.Error = message
+	r.Code = code
+	r.Fields = fields
 }
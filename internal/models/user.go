@@ -0,0 +1,7 @@
+package models
+
+// User - пользователь GitHub. ID не меняется при переименовании аккаунта, в отличие от логина.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxLoginLength - максимальная длина логина GitHub
+const maxLoginLength = 39
+
+// maxRepoNameLength - максимальная длина имени репозитория GitHub
+const maxRepoNameLength = 100
+
+var (
+	// Логин: латинские буквы, цифры и одиночные дефисы, не в начале и не в конце
+	loginPattern = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+	// Имя репозитория: латинские буквы, цифры, точка, дефис и подчёркивание
+	repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// repositoryHosts - хосты, URL репозиториев которых принимаются. Хост GitHub Enterprise Server добавляет AllowRepositoryHost.
+var repositoryHosts = []string{"github.com", "www.github.com"}
+
+// AllowRepositoryHost разрешает URL репозиториев на хосте GitHub Enterprise Server. Вызывается при запуске, до обработки запросов.
+func AllowRepositoryHost(host string) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host != "" && !isRepositoryHost(host) {
+		repositoryHosts = append(repositoryHosts, host)
+	}
+}
+
+// isRepositoryHost сообщает, принимаются ли URL репозиториев на хосте
+func isRepositoryHost(host string) bool {
+	for _, allowed := range repositoryHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRepositoryHostPrefix сообщает, начинается ли адрес без схемы с допустимого хоста, например github.com/owner/name
+func hasRepositoryHostPrefix(repository string) bool {
+	host, _, ok := strings.Cut(repository, "/")
+	return ok && isRepositoryHost(host)
+}
+
+// FieldError - ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError перечисляет все некорректные поля запроса
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// add добавляет ошибку поля, если err не nil
+func (e *ValidationError) add(field string, err error) {
+	if err != nil {
+		e.Fields = append(e.Fields, FieldError{Field: field, Message: err.Error()})
+	}
+}
+
+// errOrNil возвращает nil, если ошибок нет, чтобы не получить nil-указатель в интерфейсе error
+func (e *ValidationError) errOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NormalizeLogin проверяет логин GitHub и приводит его к нижнему регистру
+func NormalizeLogin(login string) (string, error) {
+	login = strings.TrimSpace(login)
+	switch {
+	case login == "":
+		return "", fmt.Errorf("is required")
+	case len(login) > maxLoginLength:
+		return "", fmt.Errorf("must be at most %d characters", maxLoginLength)
+	case !loginPattern.MatchString(login):
+		return "", fmt.Errorf("may only contain alphanumeric characters or single hyphens, and cannot begin or end with a hyphen")
+	}
+	return strings.ToLower(login), nil
+}
+
+// NormalizeRepository проверяет репозиторий в формате owner/name или URL https://github.com/owner/name
+// и приводит его к виду owner/name в нижнем регистре. URL других хостов, кроме github.com и GitHub Enterprise Server, отклоняются.
+func NormalizeRepository(repository string) (string, error) {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return "", fmt.Errorf("is required")
+	}
+
+	// Полный адрес репозитория: берём из него путь
+	if strings.Contains(repository, "://") || hasRepositoryHostPrefix(repository) {
+		if !strings.Contains(repository, "://") {
+			repository = "https://" + repository
+		}
+		parsed, err := url.Parse(repository)
+		if err != nil || parsed.Host == "" {
+			return "", fmt.Errorf("is not a valid repository URL")
+		}
+		if !isRepositoryHost(parsed.Host) {
+			return "", fmt.Errorf("repository URL host %q is not a GitHub host", parsed.Host)
+		}
+		if parsed.RawQuery != "" || parsed.Fragment != "" {
+			return "", fmt.Errorf("repository URL must not contain query or fragment")
+		}
+		repository = strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git")
+	}
+
+	owner, name, ok := strings.Cut(repository, "/")
+	if !ok || strings.Contains(name, "/") {
+		return "", fmt.Errorf("must be in owner/name format")
+	}
+
+	owner, err := NormalizeLogin(owner)
+	if err != nil {
+		return "", fmt.Errorf("owner %s", err)
+	}
+
+	switch {
+	case name == "":
+		return "", fmt.Errorf("repository name is required")
+	case len(name) > maxRepoNameLength:
+		return "", fmt.Errorf("repository name must be at most %d characters", maxRepoNameLength)
+	case name == "." || name == "..":
+		return "", fmt.Errorf("repository name %q is reserved", name)
+	case !repoNamePattern.MatchString(name):
+		return "", fmt.Errorf("repository name may only contain alphanumeric characters, '.', '-' and '_'")
+	}
+
+	return owner + "/" + strings.ToLower(name), nil
+}
+
+// validateUser проверяет пользователя, заданного логином или ID. Если указан ID, логин необязателен.
+func (e *ValidationError) validateUser(field, idField string, login *string, id int64) {
+	if id < 0 {
+		e.add(idField, fmt.Errorf("must be a positive number"))
+		return
+	}
+	if strings.TrimSpace(*login) == "" {
+		*login = ""
+		if id == 0 {
+			e.add(field, fmt.Errorf("is required unless %s is set", idField))
+		}
+		return
+	}
+
+	normalized, err := NormalizeLogin(*login)
+	e.add(field, err)
+	*login = normalized
+}
+
+// Validate проверяет запрос и нормализует логины
+func (r *SubscribeRequest) Validate() error {
+	var validationErr ValidationError
+
+	validationErr.validateUser("follower", "followerId", &r.Follower, r.FollowerID)
+	validationErr.validateUser("followed", "followedId", &r.Followed, r.FollowedID)
+
+	return validationErr.errOrNil()
+}
+
+// Validate проверяет запрос и нормализует логин и репозиторий
+func (r *StarCheckRequest) Validate() error {
+	var validationErr ValidationError
+
+	validationErr.validateUser("username", "userId", &r.Username, r.UserID)
+	repository, err := NormalizeRepository(r.Repository)
+	r.Repository = repository
+	validationErr.add("repository", err)
+
+	return validationErr.errOrNil()
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLogin(t *testing.T) {
+	tests := []struct {
+		login   string
+		want    string
+		wantErr bool
+	}{
+		{login: "Octocat", want: "octocat"},
+		{login: "  octo-cat  ", want: "octo-cat"},
+		{login: "a", want: "a"},
+		{login: strings.Repeat("a", maxLoginLength), want: strings.Repeat("a", maxLoginLength)},
+		{login: strings.Repeat("a", maxLoginLength+1), wantErr: true},
+		{login: "", wantErr: true},
+		{login: "-octocat", wantErr: true},
+		{login: "octocat-", wantErr: true},
+		{login: "octo--cat", wantErr: true},
+		{login: "octo_cat", wantErr: true},
+		{login: "octo/cat", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			got, err := NormalizeLogin(tt.login)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeLogin(%q) error = %v, wantErr %v", tt.login, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeLogin(%q) = %q, want %q", tt.login, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	AllowRepositoryHost("ghe.example.com")
+
+	tests := []struct {
+		repository string
+		want       string
+		wantErr    bool
+	}{
+		{repository: "Owner/Repo", want: "owner/repo"},
+		{repository: "owner/repo.js", want: "owner/repo.js"},
+		{repository: "https://github.com/Owner/Repo", want: "owner/repo"},
+		{repository: "https://github.com/owner/repo.git", want: "owner/repo"},
+		{repository: "https://www.github.com/owner/repo/", want: "owner/repo"},
+		{repository: "github.com/owner/repo", want: "owner/repo"},
+		{repository: "https://ghe.example.com/owner/repo", want: "owner/repo"},
+		{repository: "ghe.example.com/owner/repo", want: "owner/repo"},
+		{repository: "https://gitlab.com/owner/repo", wantErr: true},
+		{repository: "gitlab.com/owner/repo", wantErr: true},
+		{repository: "https://github.com/owner/repo?tab=readme", wantErr: true},
+		{repository: "https://github.com/owner", wantErr: true},
+		{repository: "", wantErr: true},
+		{repository: "owner", wantErr: true},
+		{repository: "owner/", wantErr: true},
+		{repository: "owner/repo/extra", wantErr: true},
+		{repository: "owner/..", wantErr: true},
+		{repository: "owner/re po", wantErr: true},
+		{repository: "-owner/repo", wantErr: true},
+		{repository: "owner/" + strings.Repeat("a", maxRepoNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			got, err := NormalizeRepository(tt.repository)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeRepository(%q) error = %v, wantErr %v", tt.repository, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeRepository(%q) = %q, want %q", tt.repository, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStarCheckRequestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        StarCheckRequest
+		wantFields []string
+	}{
+		{name: "login", req: StarCheckRequest{Username: "Octocat", Repository: "o/r"}},
+		{name: "id without login", req: StarCheckRequest{UserID: 583231, Repository: "o/r"}},
+		{name: "missing user", req: StarCheckRequest{Repository: "o/r"}, wantFields: []string{"username"}},
+		{name: "negative id", req: StarCheckRequest{UserID: -1, Repository: "o/r"}, wantFields: []string{"userId"}},
+		{name: "all invalid", req: StarCheckRequest{Username: "-bad", Repository: "https://gitlab.com/o/r"}, wantFields: []string{"username", "repository"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			var validationErr *ValidationError
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got %v, want ValidationError", err)
+			}
+			if len(validationErr.Fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %v, want %v", validationErr.Fields, tt.wantFields)
+			}
+			for i, field := range validationErr.Fields {
+				if field.Field != tt.wantFields[i] {
+					t.Errorf("field %d = %q, want %q", i, field.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
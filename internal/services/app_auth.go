@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// appJWTLifetime - срок жизни JWT приложения (GitHub допускает не более 10 минут)
+const appJWTLifetime = 9 * time.Minute
+
+// appJWTClockSkew - запас на расхождение часов с GitHub при выставлении iat
+const appJWTClockSkew = time.Minute
+
+// appTokenRefreshMargin - за сколько до истечения installation token запрашивается новый
+const appTokenRefreshMargin = 5 * time.Minute
+
+// AppTokenSource выдаёт installation access tokens GitHub App и обновляет их до истечения
+type AppTokenSource struct {
+	baseURL        string
+	appID          int64
+	installationID int64
+	privateKey     *rsa.PrivateKey
+	httpClient     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppTokenSource создаёт источник токенов GitHub App из ID приложения, ID установки и PEM закрытого ключа.
+// baseURL задаётся так же, как для NewHTTPClient.
+func NewAppTokenSource(baseURL string, appID, installationID int64, privateKeyPEM []byte, httpClient *http.Client) (*AppTokenSource, error) {
+	privateKey, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 15 * time.Second,
+		}
+	}
+
+	apiURL, _ := normalizeBaseURL(baseURL)
+	return &AppTokenSource{
+		baseURL:        apiURL,
+		appID:          appID,
+		installationID: installationID,
+		privateKey:     privateKey,
+		httpClient:     httpClient,
+	}, nil
+}
+
+// Token возвращает действующий installation token, при необходимости запрашивая новый
+func (s *AppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > appTokenRefreshMargin {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.requestInstallationToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiresAt = expiresAt
+	logger.Info("Obtained GitHub App installation token", "installation_id", s.installationID, "expires_at", expiresAt)
+	return token, nil
+}
+
+// invalidate сбрасывает кэшированный токен, например после ответа 401
+func (s *AppTokenSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+	s.expiresAt = time.Time{}
+}
+
+// name возвращает имя источника для логов
+func (s *AppTokenSource) name() string {
+	return "app-installation-" + strconv.FormatInt(s.installationID, 10)
+}
+
+// requestInstallationToken обменивает JWT приложения на installation token
+func (s *AppTokenSource) requestInstallationToken(ctx context.Context) (string, time.Time, error) {
+	jwt, err := s.AppJWT(time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		logger.Error("Error requesting GitHub App installation token", err)
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		apiErr := newAPIError(resp.StatusCode, url, string(body))
+		logger.Error("GitHub rejected installation token request", apiErr)
+		return "", time.Time{}, apiErr
+	}
+
+	var payload struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		logger.Error("Error decoding GitHub App installation token", err)
+		return "", time.Time{}, err
+	}
+	if payload.Token == "" {
+		return "", time.Time{}, errors.New("github returned empty installation token")
+	}
+	return payload.Token, payload.ExpiresAt, nil
+}
+
+// AppJWT подписывает JWT приложения (RS256) для момента now
+func (s *AppTokenSource) AppJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		logger.Error("Error signing GitHub App JWT", err)
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey разбирает закрытый ключ приложения в формате PKCS#1 (его выдаёт GitHub) или PKCS#8
+func parseRSAPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse github app private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
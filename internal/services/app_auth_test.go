@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAppServer - сервер GitHub, выдающий installation tokens только по JWT с верной подписью
+type fakeAppServer struct {
+	t         *testing.T
+	publicKey *rsa.PublicKey
+	appID     string
+	expiresIn time.Duration
+	requests  atomic.Int32
+}
+
+func (f *fakeAppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v3/app/installations/42/access_tokens" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := f.verifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+		f.t.Errorf("invalid app JWT: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	n := f.requests.Add(1)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":      fmt.Sprintf("ghs_token_%d", n),
+		"expires_at": time.Now().Add(f.expiresIn).UTC().Format(time.RFC3339),
+	})
+}
+
+// verifyJWT проверяет подпись RS256 и поля iss, iat и exp
+func (f *fakeAppServer) verifyJWT(jwt string) error {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts, got %d", len(parts))
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("alg = %q, want RS256", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch {
+	case claims.Iss != f.appID:
+		return fmt.Errorf("iss = %q, want %q", claims.Iss, f.appID)
+	case time.Unix(claims.Iat, 0).After(now):
+		return fmt.Errorf("iat %d is in the future", claims.Iat)
+	case !time.Unix(claims.Exp, 0).After(now):
+		return fmt.Errorf("exp %d has passed", claims.Exp)
+	case claims.Exp-claims.Iat > int64((10 * time.Minute).Seconds()):
+		return fmt.Errorf("lifetime %ds exceeds 10 minutes", claims.Exp-claims.Iat)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+
+	tests := []struct {
+		name         string
+		privateKey   []byte
+		expiresIn    time.Duration
+		wantRequests int32 // Запросов за два вызова Token
+	}{
+		{name: "cached until refresh margin", privateKey: pkcs1, expiresIn: time.Hour, wantRequests: 1},
+		{name: "pkcs8 key", privateKey: pkcs8, expiresIn: time.Hour, wantRequests: 1},
+		{name: "refreshed inside margin", privateKey: pkcs1, expiresIn: appTokenRefreshMargin - time.Minute, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAppServer{t: t, publicKey: &key.PublicKey, appID: "123", expiresIn: tt.expiresIn}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			source, err := NewAppTokenSource(server.URL, 123, 42, tt.privateKey, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			first, err := source.Token(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := source.Token(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fake.requests.Load(); got != tt.wantRequests {
+				t.Errorf("token requests = %d, want %d", got, tt.wantRequests)
+			}
+			if cached := tt.wantRequests == 1; cached != (first == second) {
+				t.Errorf("tokens %q and %q, want cached = %v", first, second, cached)
+			}
+		})
+	}
+}
+
+// После invalidate (ответ 401) токен запрашивается заново
+func TestAppTokenSourceInvalidate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeAppServer{t: t, publicKey: &key.PublicKey, appID: "123", expiresIn: time.Hour}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	source, err := NewAppTokenSource(server.URL, 123, 42, privateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.invalidate()
+	second, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || fake.requests.Load() != 2 {
+		t.Errorf("got %q then %q after %d requests, want a new token", first, second, fake.requests.Load())
+	}
+}
+
+// Сервер отклоняет JWT, подписанный другим ключом
+func TestAppTokenSourceWrongKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	source, err := NewAppTokenSource("https://api.github.com", 123, 42, privateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := source.AppJWT(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeAppServer{publicKey: &other.PublicKey, appID: "123"}
+	if err := fake.verifyJWT(jwt); err == nil {
+		t.Fatal("JWT signed with a different key was accepted")
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh-checker/internal/database"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBatchConcurrency - сколько групп пакетной проверки обрабатывается параллельно
+const DefaultBatchConcurrency = 4
+
+// StarCheck - одна проверка звезды в пакете
+type StarCheck struct {
+	User       models.User
+	Repository string
+}
+
+// FollowCheck - одна проверка подписки в пакете
+type FollowCheck struct {
+	Follower models.User
+	User     models.User
+}
+
+// BatchResult - результат одной проверки пакета. Ошибка относится только к этой проверке.
+type BatchResult struct {
+	CheckResult
+	Err error
+}
+
+// CheckStarredBatch проверяет звёзды для пакета пар пользователь-репозиторий.
+// Проверки группируются по репозиторию: список звёзд каждого репозитория загружается не больше одного раза.
+// Если список звёзд репозитория не загружается целиком, пользователи проверяются по своим звёздам.
+func CheckStarredBatch(ctx context.Context, client GitHubClient, checks []StarCheck, updateInterval time.Duration, concurrency int) []BatchResult {
+	groups := make(map[string][]int)
+	for i, check := range checks {
+		groups[check.Repository] = append(groups[check.Repository], i)
+	}
+
+	results := make([]BatchResult, len(checks))
+	runGroups(ctx, groups, concurrency, func(ctx context.Context, repository string, items []int) {
+		// Для одной проверки дешевле выбрать стратегию, чем загружать все звёзды репозитория
+		if len(items) == 1 {
+			check := checks[items[0]]
+			result, err := CheckStarred(ctx, client, check.User, repository, updateInterval)
+			results[items[0]] = BatchResult{CheckResult: result, Err: err}
+			return
+		}
+
+		updated, err := UpdateStargazers(ctx, client, repository, updateInterval)
+		if errors.Is(err, errIncompleteStargazers) {
+			// Звёзды репозитория не загрузить целиком: проверяем каждого пользователя по его звёздам
+			for _, i := range items {
+				result, err := checkStarredByUser(ctx, client, checks[i].User, repository, updateInterval)
+				results[i] = BatchResult{CheckResult: result, Err: err}
+			}
+			logger.Info(fmt.Sprintf("Checked %d users against their own stars for %s", len(items), repository))
+			return
+		}
+		if err != nil {
+			for _, i := range items {
+				results[i].Err = err
+			}
+			return
+		}
+
+		strategy := StrategyCache
+		if updated {
+			strategy = StrategyScanStargazers
+		}
+		for _, i := range items {
+			hasStar, err := database.IsStargazer(ctx, repository, checks[i].User.ID)
+			results[i] = BatchResult{CheckResult: CheckResult{Result: hasStar, Strategy: strategy}, Err: err}
+		}
+		logger.Info(fmt.Sprintf("Checked %d users against stargazers of %s", len(items), repository))
+	})
+
+	return results
+}
+
+// CheckFollowingBatch проверяет подписки для пакета пар подписчик-пользователь.
+// Проверки группируются по пользователю: список его подписчиков загружается не больше одного раза.
+func CheckFollowingBatch(ctx context.Context, client GitHubClient, checks []FollowCheck, updateInterval time.Duration, concurrency int) []BatchResult {
+	groups := make(map[int64][]int)
+	for i, check := range checks {
+		groups[check.User.ID] = append(groups[check.User.ID], i)
+	}
+
+	results := make([]BatchResult, len(checks))
+	runGroups(ctx, groups, concurrency, func(ctx context.Context, _ int64, items []int) {
+		// Одну подписку проверяет отдельный эндпоинт без загрузки списка
+		if len(items) == 1 {
+			check := checks[items[0]]
+			result, err := CheckFollowing(ctx, client, check.Follower, check.User, updateInterval)
+			results[items[0]] = BatchResult{CheckResult: result, Err: err}
+			return
+		}
+
+		user := checks[items[0]].User
+		followers, updated, err := UpdateFollowers(ctx, client, user, updateInterval)
+		if err != nil {
+			for _, i := range items {
+				results[i].Err = err
+			}
+			return
+		}
+
+		strategy := StrategyCache
+		if updated {
+			strategy = StrategyScanFollowers
+		}
+		followerIDs := make(map[int64]bool, len(followers))
+		for _, follower := range followers {
+			followerIDs[follower.ID] = true
+		}
+		for _, i := range items {
+			results[i].CheckResult = CheckResult{Result: followerIDs[checks[i].Follower.ID], Strategy: strategy}
+		}
+	})
+
+	return results
+}
+
+// runGroups обрабатывает группы проверок параллельно, не больше concurrency групп одновременно
+func runGroups[K comparable](ctx context.Context, groups map[K][]int, concurrency int, process func(ctx context.Context, key K, items []int)) {
+	if concurrency < 1 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for key, items := range groups {
+		g.Go(func() error {
+			process(ctx, key, items)
+			return nil
+		})
+	}
+	g.Wait()
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gh-checker/internal/lib/metrics"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// inflight объединяет одновременные обновления одного ресурса
+var inflight singleflight.Group
+
+// coalesce выполняет fetch один раз для всех одновременных вызовов с тем же ресурсом и ключом.
+// Остальные вызовы ждут его результата, но не дольше срока своего контекста.
+// Если ведущий вызов прерван отменой своего контекста, ожидающий повторяет запрос со своим.
+func coalesce[T any](ctx context.Context, resource, key string, fetch func(ctx context.Context) (T, error)) (T, error) {
+	for {
+		leader := false
+		ch := inflight.DoChan(resource+":"+key, func() (any, error) {
+			leader = true
+			return fetch(ctx)
+		})
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case res := <-ch:
+			if !leader {
+				if res.Err != nil && ctx.Err() == nil && (errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded)) {
+					continue
+				}
+				metrics.CoalescedCalls.WithLabelValues(resource).Inc()
+			}
+			value, _ := res.Val.(T)
+			return value, res.Err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Доменные ошибки сервиса. Обработчики HTTP определяют по ним статус ответа через errors.Is.
+// ErrRateLimited объявлена в ratelimit.go.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrUserNotFound        = errors.New("github user not found")
+	ErrRepoNotFound        = errors.New("github repository not found")
+	ErrUnauthorized        = errors.New("github rejected credentials")
+	ErrForbidden           = errors.New("github denied access to the resource")
+	ErrUpstreamUnavailable = errors.New("github api unavailable")
+	ErrInvalidInput        = errors.New("invalid input")
+)
+
+// Виды объектов GitHub, к которым относится ошибка 404
+const (
+	resourceKindUser       = "user"
+	resourceKindRepository = "repository"
+)
+
+// APIError - ответ GitHub API с неуспешным статусом
+type APIError struct {
+	StatusCode int
+	URL        string
+	Message    string
+	Resource   string // user или repository для ответов 404
+}
+
+// newAPIError создаёт ошибку API, определяя по адресу, к какому объекту она относится
+func newAPIError(statusCode int, url, message string) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, URL: url, Message: message}
+	switch {
+	case strings.Contains(url, "/repos/"):
+		apiErr.Resource = resourceKindRepository
+	case strings.Contains(url, "/users/"), strings.Contains(url, "/user/"):
+		apiErr.Resource = resourceKindUser
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API error %d for %s: %s", e.StatusCode, e.URL, e.Message)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrUserNotFound) и аналогичные
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUserNotFound:
+		return e.StatusCode == http.StatusNotFound && e.Resource == resourceKindUser
+	case ErrRepoNotFound:
+		return e.StatusCode == http.StatusNotFound && e.Resource == resourceKindRepository
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrUpstreamUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
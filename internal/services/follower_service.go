@@ -1,28 +1,33 @@
 package services
 
 import (
+	"context"
 	"gh-checker/internal/database"
 	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"strconv"
 	"time"
 )
 
 // UpdateFollowers проверяет, нужно ли обновить подписчиков и обновляет их, если необходимо.
 // Если обновление не требуется, возвращает кэшированные данные.
-func UpdateFollowers(username string, updateInterval time.Duration) ([]string, bool, error) {
+func UpdateFollowers(ctx context.Context, client GitHubClient, user models.User, updateInterval time.Duration) ([]models.User, bool, error) {
+	username := user.Login
 	logger.Info("Starting follower update process for user " + username)
 
 	// Проверка необходимости обновления подписчиков
 	logger.Info("Checking if followers need to be updated for user " + username)
-	shouldUpdate, err := database.ShouldUpdateFollowers(username, updateInterval)
+	shouldUpdate, err := database.ShouldUpdateFollowers(ctx, user.ID, updateInterval)
 	if err != nil {
 		logger.Error("Error checking if followers need to be updated for user "+username, err)
 		return nil, false, err
 	}
 
 	if !shouldUpdate {
+		observeCache("followers", "hit")
 		logger.Info("No update needed for user " + username + ". Retrieving cached followers.")
 		// Возвращаем кэшированные данные
-		followers, err := database.GetFollowers(username)
+		followers, err := database.GetFollowers(ctx, user.ID)
 		if err != nil {
 			logger.Error("Error retrieving cached followers for user "+username, err)
 			return nil, false, err
@@ -31,42 +36,64 @@ func UpdateFollowers(username string, updateInterval time.Duration) ([]string, b
 		return followers, false, nil
 	}
 
-	// Обновление подписчиков через GitHub API
-	logger.Info("Updating followers for user " + username + " via GitHub API")
-	newFollowers, err := GetFollowers(username) // Здесь должен быть вызов GitHub API
+	// Обновление подписчиков через GitHub API. Одновременные запросы об одном пользователе ждут одну загрузку,
+	// поэтому обновление учитывается только в ней.
+	newFollowers, err := coalesce(ctx, "followers", strconv.FormatInt(user.ID, 10), func(ctx context.Context) ([]models.User, error) {
+		observeCache("followers", "refresh")
+		logger.Info("Updating followers for user " + username + " via GitHub API")
+		followers, err := client.GetFollowers(ctx, username)
+		if err != nil {
+			logger.Error("Error retrieving followers from GitHub API for user "+username, err)
+			return nil, err
+		}
+
+		// Замена подписчиков и времени проверки одной транзакцией
+		logger.Info("Replacing followers for user " + username)
+		if err := database.ReplaceFollowers(ctx, user, followers); err != nil {
+			logger.Error("Error replacing followers for user "+username, err)
+			return nil, err
+		}
+		return followers, nil
+	})
 	if err != nil {
-		logger.Error("Error retrieving followers from GitHub API for user "+username, err)
 		return nil, false, err
 	}
 
-	// Очистка старых подписчиков
-	logger.Info("Clearing old followers for user " + username)
-	err = database.ClearFollowers(username)
+	logger.Info("Successfully updated followers for user " + username)
+	return newFollowers, true, nil
+}
+
+// CheckFollowing проверяет, подписан ли follower на username.
+// Если список подписчиков username в кэше свежий, ответ берётся из него, иначе используется прямой эндпоинт GitHub.
+func CheckFollowing(ctx context.Context, client GitHubClient, followerUser, user models.User, updateInterval time.Duration) (CheckResult, error) {
+	follower, username := followerUser.Login, user.Login
+
+	shouldUpdate, err := database.ShouldUpdateFollowers(ctx, user.ID, updateInterval)
 	if err != nil {
-		logger.Error("Error clearing followers for user "+username, err)
-		return nil, false, err
+		logger.Error("Error checking if followers need to be updated for user "+username, err)
+		return CheckResult{}, err
 	}
 
-	// Добавление новых подписчиков в базу данных
-	logger.Info("Adding new followers for user " + username)
-	for _, follower := range newFollowers {
-		logger.Info("Adding follower " + follower + " for user " + username)
-		err := database.AddFollower(username, follower)
+	if !shouldUpdate {
+		observeCache("followers", "hit")
+		isFollowing, err := database.IsFollowing(ctx, followerUser.ID, user.ID)
 		if err != nil {
-			logger.Error("Error adding follower "+follower+" -> "+username, err)
-		} else {
-			logger.Info("Successfully added follower " + follower + " for user " + username)
+			return CheckResult{}, err
 		}
+		logger.Info("Answered follow check for " + follower + " -> " + username + " from cache")
+		return CheckResult{Result: isFollowing, Strategy: StrategyCache}, nil
 	}
 
-	// Обновление времени последней проверки подписчиков
-	logger.Info("Updating last checked timestamp for user " + username)
-	err = database.UpdateLastCheckedFollowers(username) // Используем функцию для подписчиков
+	// Один запрос вместо загрузки всего списка подписчиков, общий для одновременных проверок той же пары
+	observeCache("followers", "miss")
+	key := strconv.FormatInt(followerUser.ID, 10) + ":" + strconv.FormatInt(user.ID, 10)
+	isFollowing, err := coalesce(ctx, "following", key, func(ctx context.Context) (bool, error) {
+		return client.IsFollowing(ctx, follower, username)
+	})
 	if err != nil {
-		logger.Error("Error updating last checked timestamp for user "+username, err)
-		return nil, false, err
+		logger.Error("Error checking follow "+follower+" -> "+username+" via GitHub API", err)
+		return CheckResult{}, err
 	}
 
-	logger.Info("Successfully updated followers for user " + username)
-	return newFollowers, true, nil
+	return CheckResult{Result: isFollowing, Strategy: StrategyDirect}, nil
 }
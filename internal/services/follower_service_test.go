@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeFollowersClient отдаёт подписчиков после сигнала release, чтобы одновременные вызовы успели объединиться
+type fakeFollowersClient struct {
+	GitHubClient
+	followers []models.User
+	release   chan struct{}
+
+	fetches atomic.Int32
+}
+
+func (c *fakeFollowersClient) GetFollowers(ctx context.Context, username string) ([]models.User, error) {
+	c.fetches.Add(1)
+	<-c.release
+	return c.followers, nil
+}
+
+// Обновлением кэша считается только загрузка из GitHub, а не ожидание чужой загрузки
+func TestUpdateFollowersCountsOneRefreshPerFetch(t *testing.T) {
+	const callers = 5
+	client := &fakeFollowersClient{
+		followers: []models.User{{ID: 3002, Login: "follower"}},
+		release:   make(chan struct{}),
+	}
+	user := models.User{ID: time.Now().UnixNano(), Login: "popular"}
+	refreshes := metrics.CacheLookups.WithLabelValues("followers", "refresh")
+	before := testutil.ToFloat64(refreshes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := UpdateFollowers(context.Background(), client, user, time.Hour); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(client.release)
+	wg.Wait()
+
+	fetches := client.fetches.Load()
+	if fetches >= callers {
+		t.Errorf("got %d fetches for %d concurrent callers, want them coalesced", fetches, callers)
+	}
+	if got := testutil.ToFloat64(refreshes) - before; got != float64(fetches) {
+		t.Errorf("got %v refreshes, want %d (one per fetch)", got, fetches)
+	}
+}
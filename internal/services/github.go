@@ -1,186 +1,476 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
-const githubAPI = "https://api.github.com"
+// DefaultBaseURL - адрес публичного GitHub API
+const DefaultBaseURL = "https://api.github.com"
 
-// Ограничение на количество подписчиков, загружаемых за один запрос
-const maxFollowersPerPage = 100
+// DefaultAPIVersion - версия REST API, запрашиваемая у github.com
+const DefaultAPIVersion = "2022-11-28"
+
+// enterpriseAPIPath - префикс REST API на GitHub Enterprise Server
+const enterpriseAPIPath = "/api/v3"
+
+// DefaultPageConcurrency - сколько страниц одного списка загружается параллельно
+const DefaultPageConcurrency = 4
+
+// DefaultMaxRateLimitWait - максимальное время, которое клиент готов ждать сброса лимита вместо ошибки
+const DefaultMaxRateLimitWait = 10 * time.Second
 
-var githubAPIKey string
+// Максимальный размер страницы, который GitHub отдаёт за один запрос
+const maxFollowersPerPage = 100
 
-// SetGitHubAPIKey устанавливает API ключ GitHub
-func SetGitHubAPIKey(apiKey string) {
-	githubAPIKey = apiKey
-	logger.Info("GitHub API key set")
+// GitHubClient описывает обращения сервиса к GitHub
+type GitHubClient interface {
+	// GetUser возвращает пользователя по логину
+	GetUser(ctx context.Context, login string) (models.User, error)
+	// GetUserByID возвращает пользователя по числовому ID
+	GetUserByID(ctx context.Context, id int64) (models.User, error)
+	// GetFollowers возвращает подписчиков пользователя
+	GetFollowers(ctx context.Context, username string) ([]models.User, error)
+	// GetStargazers возвращает пользователей, поставивших звезду на репозиторий
+	GetStargazers(ctx context.Context, repository string) ([]models.User, error)
+	// CheckStarred проверяет, поставил ли пользователь звезду на репозиторий, просматривая звёзды пользователя
+	CheckStarred(ctx context.Context, username, repository string) (bool, error)
+	// IsFollowing проверяет подписку follower на username одним запросом
+	IsFollowing(ctx context.Context, follower, username string) (bool, error)
+	// CountStargazers возвращает количество звёзд репозитория
+	CountStargazers(ctx context.Context, repository string) (int, error)
+	// CountStarred возвращает количество репозиториев, отмеченных пользователем
+	CountStarred(ctx context.Context, username string) (int, error)
+	// RateLimit проверяет каждый токен пула и возвращает состояние его лимита запросов
+	RateLimit(ctx context.Context) ([]TokenRateLimit, error)
 }
 
-// GetFollowers получает подписчиков пользователя с GitHub API
-func GetFollowers(username string) ([]string, error) {
-	var allFollowers []string
-	page := 1
+// HTTPClient - реализация GitHubClient поверх REST API
+type HTTPClient struct {
+	baseURL    string
+	apiVersion string
+	enterprise bool
+	tokens     *TokenPool
+	httpClient *http.Client
 
-	logger.Info("Starting to fetch followers for user " + username)
+	maxRateLimitWait time.Duration
+	retryPolicy      RetryPolicy
 
-	for {
-		url := fmt.Sprintf("%s/users/%s/followers?per_page=%d&page=%d", githubAPI, username, maxFollowersPerPage, page)
+	cache ResponseCache // Кэш ответов для условных запросов, nil - кэширование отключено
 
-		logger.Info(fmt.Sprintf("Requesting followers for %s from GitHub API (page %d)", username, page))
-		resp, err := makeGitHubAPIRequestWithRetries(url)
-		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to get followers for %s (page %d)", username, page), err)
-			return nil, err
-		}
+	pageConcurrency int
+}
 
-		// Обрабатываем результат запроса
-		var followers []struct {
-			Login string `json:"login"`
+// NewHTTPClient создаёт клиент REST API GitHub.
+// Пустой baseURL заменяется на DefaultBaseURL, nil httpClient - на клиент с таймаутом 15 секунд.
+// Для GitHub Enterprise Server можно передать как адрес инстанса, так и адрес API (https://ghe.example.com/api/v3).
+// Запросы распределяются между токенами пула; nil пул означает запросы без авторизации.
+func NewHTTPClient(baseURL string, tokens *TokenPool, httpClient *http.Client) *HTTPClient {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 15 * time.Second, // Увеличенный таймаут на запрос
 		}
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&followers); err != nil {
-			logger.Error(fmt.Sprintf("Error decoding followers from GitHub for %s", username), err)
-			resp.Body.Close() // Закрытие тела ответа при ошибке
-			return nil, err
-		}
+	if tokens == nil {
+		tokens = NewTokenPool()
+	}
+
+	apiURL, enterprise := normalizeBaseURL(baseURL)
+	apiVersion := DefaultAPIVersion
+	if enterprise {
+		// Старые версии GHES не знают про версионирование API, поэтому по умолчанию заголовок не отправляем
+		apiVersion = ""
+		logger.Info("Using GitHub Enterprise Server API at " + apiURL)
+	}
 
-		resp.Body.Close() // Закрытие тела после успешного получения данных
+	return &HTTPClient{
+		baseURL:          apiURL,
+		apiVersion:       apiVersion,
+		enterprise:       enterprise,
+		tokens:           tokens,
+		httpClient:       httpClient,
+		maxRateLimitWait: DefaultMaxRateLimitWait,
+		pageConcurrency:  DefaultPageConcurrency,
+		retryPolicy:      DefaultRetryPolicy(),
+	}
+}
 
-		// Добавляем новых подписчиков в общий список
-		for _, follower := range followers {
-			allFollowers = append(allFollowers, follower.Login)
-		}
+// SetAPIVersion задаёт значение заголовка X-GitHub-Api-Version (пустая строка отключает заголовок)
+func (c *HTTPClient) SetAPIVersion(version string) {
+	c.apiVersion = version
+}
 
-		// Логируем, сколько подписчиков было обработано на текущей странице
-		logger.Info(fmt.Sprintf("Processed %d followers for %s from GitHub (page %d)", len(followers), username, page))
+// SetMaxRateLimitWait задаёт, сколько клиент может ждать сброса лимита, прежде чем вернуть ErrRateLimited
+func (c *HTTPClient) SetMaxRateLimitWait(wait time.Duration) {
+	c.maxRateLimitWait = wait
+}
 
-		// Если количество подписчиков меньше максимального на странице, значит больше страниц нет
-		if len(followers) < maxFollowersPerPage {
-			break
-		}
+// SetRetryPolicy задаёт политику повторных попыток
+func (c *HTTPClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetResponseCache включает условные запросы с If-None-Match через переданный кэш
+func (c *HTTPClient) SetResponseCache(cache ResponseCache) {
+	c.cache = cache
+}
+
+// SetPageConcurrency задаёт число параллельно загружаемых страниц одного списка (1 - последовательно)
+func (c *HTTPClient) SetPageConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	c.pageConcurrency = concurrency
+}
+
+// normalizeBaseURL приводит адрес API к каноническому виду и определяет, указывает ли он на GitHub Enterprise Server
+func normalizeBaseURL(baseURL string) (string, bool) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return DefaultBaseURL, false
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return baseURL, false
+	}
 
-		// Увеличиваем номер страницы
-		page++
+	if strings.EqualFold(parsed.Host, "api.github.com") || strings.EqualFold(parsed.Host, "github.com") {
+		return DefaultBaseURL, false
 	}
 
-	logger.Info(fmt.Sprintf("Retrieved %d followers for %s from GitHub", len(allFollowers), username))
-	return allFollowers, nil
+	// На GHES REST API доступен по префиксу /api/v3
+	if parsed.Path == "" {
+		parsed.Path = enterpriseAPIPath
+	}
+	return parsed.String(), true
 }
 
-// makeGitHubAPIRequest выполняет HTTP-запрос к GitHub API и обрабатывает возможные ошибки с повторными попытками
-func makeGitHubAPIRequestWithRetries(url string) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	maxAttempts := 3
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		logger.Info(fmt.Sprintf("Attempt %d to make GitHub API request to %s", attempt, url))
-		resp, err = makeGitHubAPIRequest(url)
-		if err == nil {
-			return resp, nil
-		}
-		logger.Error(fmt.Sprintf("Error making GitHub API request to %s (attempt %d)", url, attempt), err)
+// EnterpriseHost возвращает хост GitHub Enterprise Server из адреса API или пустую строку для github.com
+func EnterpriseHost(baseURL string) string {
+	apiURL, enterprise := normalizeBaseURL(baseURL)
+	if !enterprise {
+		return ""
+	}
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
 
-		// Если последняя попытка, возвращаем ошибку
-		if attempt == maxAttempts {
-			return nil, err
-		}
+// GetUser получает пользователя через GET /users/{login}
+func (c *HTTPClient) GetUser(ctx context.Context, login string) (models.User, error) {
+	return c.getUser(ctx, fmt.Sprintf("%s/users/%s", c.baseURL, login))
+}
+
+// GetUserByID получает пользователя через GET /user/{id}
+func (c *HTTPClient) GetUserByID(ctx context.Context, id int64) (models.User, error) {
+	return c.getUser(ctx, fmt.Sprintf("%s/user/%d", c.baseURL, id))
+}
+
+// getUser запрашивает профиль пользователя и возвращает его ID и текущий логин
+func (c *HTTPClient) getUser(ctx context.Context, url string) (models.User, error) {
+	resp, err := c.makeGitHubAPIRequestWithRetries(ctx, url)
+	if err != nil {
+		logger.Error("Failed to get user from "+url, err)
+		return models.User{}, err
+	}
+	defer resp.Body.Close()
 
-		// Ждем перед повторной попыткой
-		time.Sleep(2 * time.Second)
+	var user models.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		logger.Error("Error decoding user from "+url, err)
+		return models.User{}, err
 	}
 
-	return nil, err
+	logger.Info(fmt.Sprintf("Resolved user %s with id %d", user.Login, user.ID))
+	return user, nil
 }
 
-// makeGitHubAPIRequest выполняет HTTP-запрос к GitHub API и обрабатывает возможные ошибки
-func makeGitHubAPIRequest(url string) (*http.Response, error) {
-	logger.Info("Making GitHub API request to " + url)
+// GetFollowers получает подписчиков пользователя с GitHub API
+func (c *HTTPClient) GetFollowers(ctx context.Context, username string) ([]models.User, error) {
+	logger.Info("Starting to fetch followers for user " + username)
+
+	url := fmt.Sprintf("%s/users/%s/followers", c.baseURL, username)
+	followers, err := c.listUsers(ctx, url)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get followers for %s", username), err)
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Retrieved %d followers for %s from GitHub", len(followers), username))
+	return followers, nil
+}
+
+// GetStargazers получает пользователей, поставивших звезду на репозиторий
+func (c *HTTPClient) GetStargazers(ctx context.Context, repository string) ([]models.User, error) {
+	logger.Info("Starting to fetch stargazers for repository " + repository)
+
+	url := fmt.Sprintf("%s/repos/%s/stargazers", c.baseURL, repository)
+	stargazers, err := c.listUsers(ctx, url)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get stargazers for %s", repository), err)
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Retrieved %d stargazers for %s from GitHub", len(stargazers), repository))
+	return stargazers, nil
+}
 
-	client := &http.Client{
-		Timeout: 15 * time.Second, // Увеличенный таймаут на запрос
+// listUsers загружает все страницы списка пользователей
+func (c *HTTPClient) listUsers(ctx context.Context, listURL string) ([]models.User, error) {
+	it := newPageIterator(c, listURL)
+	pages, err := fetchAllPages[models.User](ctx, it, c.pageConcurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []models.User
+	for page, users := range pages {
+		all = append(all, users...)
+
+		// Логируем, сколько пользователей было обработано на каждой странице
+		logger.Info(fmt.Sprintf("Processed %d users from %s (page %d of %d)", len(users), listURL, page+1, len(pages)))
+	}
+
+	return all, nil
+}
+
+// makeGitHubAPIRequestWithRetries выполняет HTTP-запрос к GitHub API и обрабатывает возможные ошибки с повторными попытками
+func (c *HTTPClient) makeGitHubAPIRequestWithRetries(ctx context.Context, url string) (*http.Response, error) {
+	var resp *http.Response
+	err := doWithRetries(ctx, c.tokens, resourceCore, c.retryPolicy, c.maxRateLimitWait, url, func(token *pooledToken) error {
+		var err error
+		resp, err = c.makeGitHubAPIRequest(ctx, url, token, c.cache)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	req, err := http.NewRequest("GET", url, nil)
+	return resp, nil
+}
+
+// makeGitHubAPIRequest выполняет HTTP-запрос к GitHub API и обрабатывает возможные ошибки.
+// cache - кэш ответов для условного запроса, nil - запрос без кэша.
+func (c *HTTPClient) makeGitHubAPIRequest(ctx context.Context, url string, token *pooledToken, cache ResponseCache) (*http.Response, error) {
+	logger.Info("Making GitHub API request to " + url)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		logger.Error("Error creating GitHub API request", err)
 		return nil, err
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if c.apiVersion != "" {
+		req.Header.Set("X-GitHub-Api-Version", c.apiVersion)
+	}
+
+	credential, err := token.credential(ctx)
+	if err != nil {
+		logger.Error("Error obtaining GitHub credential", err)
+		return nil, err
+	}
+	if credential != "" {
+		req.Header.Set("Authorization", "token "+credential)
+	}
 
-	if githubAPIKey != "" {
-		req.Header.Set("Authorization", "token "+githubAPIKey)
+	var cached CachedResponse
+	hasCached := false
+	if cache != nil {
+		cached, hasCached = cache.Get(ctx, url)
+		if hasCached {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
 	}
 
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Error making GitHub API request to %s", url), err)
 		return nil, err
 	}
 
+	token.updateLimits(resourceCore, resp.Header)
+
+	// Страница не изменилась: отдаём сохранённую копию, такой запрос не расходует квоту
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		resp.Body.Close()
+		cache.Touch(ctx, url)
+		logger.Info(fmt.Sprintf("GitHub API request to %s not modified, using cached response", url))
+		return &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Header:     cached.Header,
+			Body:       io.NopCloser(bytes.NewReader(cached.Body)),
+			Request:    req,
+		}, nil
+	}
+
 	// Проверяем на ошибки статуса
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		body, _ := io.ReadAll(resp.Body)
-		logger.Error(fmt.Sprintf("GitHub API error for %s", url), fmt.Errorf("%s", string(body)))
 		if closeErr := resp.Body.Close(); closeErr != nil { // Обработка ошибки при закрытии
 			logger.Error("Error closing response body after API error", closeErr)
 		}
-		return nil, fmt.Errorf("GitHub API error: %s", string(body))
+
+		if rlErr := parseRateLimitError(resp, body); rlErr != nil {
+			logger.Error(fmt.Sprintf("GitHub API rate limit for %s", url), rlErr)
+			return nil, rlErr
+		}
+
+		apiErr := newAPIError(resp.StatusCode, url, string(body))
+		logger.Error(fmt.Sprintf("GitHub API error for %s", url), apiErr)
+		return nil, apiErr
+	}
+
+	if etag := resp.Header.Get("ETag"); cache != nil && etag != "" {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error reading GitHub API response from %s", url), err)
+			return nil, err
+		}
+		cache.Put(ctx, url, CachedResponse{ETag: etag, Header: resp.Header, Body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
 	}
 
 	logger.Info(fmt.Sprintf("GitHub API request to %s succeeded", url))
 	return resp, nil
 }
 
-// CheckStar проверяет, поставил ли пользователь звезду на репозиторий
-func CheckStar(username, repository string) (bool, error) {
-	page := 1
+// CheckStarred проверяет, поставил ли пользователь звезду на репозиторий, просматривая список его звёзд
+func (c *HTTPClient) CheckStarred(ctx context.Context, username, repository string) (bool, error) {
+	logger.Info(fmt.Sprintf("Checking starred repositories of user %s for %s", username, repository))
+
+	hasStar := false
+	it := newPageIterator(c, fmt.Sprintf("%s/users/%s/starred", c.baseURL, username))
+	err := forEachPage(ctx, it, func(repos []struct {
+		FullName string `json:"full_name"`
+	}) bool {
+		for _, repo := range repos {
+			if strings.EqualFold(repo.FullName, repository) {
+				hasStar = true
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check starred repositories of user %s", username), err)
+		return false, err
+	}
 
-	for {
-		url := fmt.Sprintf("%s/repos/%s/stargazers?per_page=%d&page=%d", githubAPI, repository, maxFollowersPerPage, page)
+	logger.Info(fmt.Sprintf("Starred repositories of user %s checked for %s", username, repository), "has_star", hasStar)
+	return hasStar, nil
+}
 
-		logger.Info(fmt.Sprintf("Checking if user %s starred repository %s (page %d)", username, repository, page))
-		resp, err := makeGitHubAPIRequestWithRetries(url)
-		if err != nil {
-			logger.Error(fmt.Sprintf("Failed to check star for user %s on repository %s", username, repository), err)
-			return false, err
-		}
+// IsFollowing проверяет подписку через GET /users/{follower}/following/{username}: 204 - подписан, 404 - нет
+func (c *HTTPClient) IsFollowing(ctx context.Context, follower, username string) (bool, error) {
+	url := fmt.Sprintf("%s/users/%s/following/%s", c.baseURL, follower, username)
 
-		var stargazers []struct {
-			Login string `json:"login"`
+	resp, err := c.makeGitHubAPIRequestWithRetries(ctx, url)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			logger.Info(fmt.Sprintf("User %s is not following %s", follower, username))
+			return false, nil
 		}
+		logger.Error(fmt.Sprintf("Failed to check if %s follows %s", follower, username), err)
+		return false, err
+	}
+	resp.Body.Close()
 
-		if err := json.NewDecoder(resp.Body).Decode(&stargazers); err != nil {
-			logger.Error(fmt.Sprintf("Error decoding stargazers from GitHub for %s", repository), err)
-			resp.Body.Close() // Закрываем тело ответа при ошибке
-			return false, err
-		}
+	logger.Info(fmt.Sprintf("User %s is following %s", follower, username))
+	return true, nil
+}
 
-		resp.Body.Close() // Закрываем тело после успешного получения данных
+// RateLimit возвращает состояние лимита REST API каждого токена из GET /rate_limit.
+// Этот запрос не расходует квоту и выполняется без кэша ответов: его результат меняется с каждым запросом.
+func (c *HTTPClient) RateLimit(ctx context.Context) ([]TokenRateLimit, error) {
+	return c.tokens.checkEachToken(ctx, c.tokenRateLimit)
+}
 
-		// Проверяем, есть ли пользователь среди тех, кто поставил звезду
-		for _, stargazer := range stargazers {
-			if stargazer.Login == username {
-				logger.Info(fmt.Sprintf("User %s has starred repository %s", username, repository))
-				return true, nil
-			}
+// tokenRateLimit возвращает состояние лимита REST API одного токена
+func (c *HTTPClient) tokenRateLimit(ctx context.Context, token *pooledToken) (RateLimitUsage, error) {
+	resp, err := c.makeGitHubAPIRequest(ctx, c.baseURL+"/rate_limit", token, nil)
+	if err != nil {
+		// GitHub Enterprise Server с отключённым лимитом отвечает 404
+		if c.enterprise && errors.Is(err, ErrNotFound) {
+			return RateLimitUsage{}, ErrRateLimitDisabled
 		}
+		logger.Error("Failed to get rate limit", err, "token", token.name())
+		return RateLimitUsage{}, err
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		logger.Error("Error decoding rate limit", err)
+		return RateLimitUsage{}, err
+	}
 
-		// Если количество "звезд" меньше максимального на странице, значит больше страниц нет
-		if len(stargazers) < maxFollowersPerPage {
-			break
-		}
+	core := payload.Resources.Core
+	return RateLimitUsage{Limit: core.Limit, Remaining: core.Remaining, Reset: time.Unix(core.Reset, 0)}, nil
+}
+
+// CountStargazers возвращает количество звёзд репозитория из GET /repos/{repository}
+func (c *HTTPClient) CountStargazers(ctx context.Context, repository string) (int, error) {
+	resp, err := c.makeGitHubAPIRequestWithRetries(ctx, fmt.Sprintf("%s/repos/%s", c.baseURL, repository))
+	if err != nil {
+		logger.Error("Failed to get repository "+repository, err)
+		return 0, err
+	}
+	defer resp.Body.Close()
 
-		// Переход к следующей странице
-		page++
+	var repo struct {
+		StargazersCount int `json:"stargazers_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		logger.Error("Error decoding repository "+repository, err)
+		return 0, err
+	}
+	return repo.StargazersCount, nil
+}
+
+// CountStarred возвращает количество звёзд пользователя.
+// Счётчика в профиле нет, поэтому запрашиваем страницу размером 1: номер последней страницы равен количеству.
+func (c *HTTPClient) CountStarred(ctx context.Context, username string) (int, error) {
+	url := withQuery(fmt.Sprintf("%s/users/%s/starred", c.baseURL, username), "per_page", "1")
+	resp, err := c.makeGitHubAPIRequestWithRetries(ctx, url)
+	if err != nil {
+		logger.Error("Failed to count starred repositories of user "+username, err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if last, ok := parseLinkHeader(resp.Header.Get("Link"))["last"]; ok {
+		return pageNumber(last), nil
 	}
 
-	logger.Info(fmt.Sprintf("User %s has not starred repository %s", username, repository))
-	return false, nil
+	// Ссылок нет - все звёзды поместились на одной странице
+	var repos []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
+		logger.Error("Error decoding starred repositories of user "+username, err)
+		return 0, err
+	}
+	return len(repos), nil
 }
@@ -0,0 +1,535 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Бэкенды GitHub API, между которыми можно переключаться в конфигурации
+const (
+	BackendREST    = "rest"
+	BackendGraphQL = "graphql"
+)
+
+// rateLimitFragment запрашивается в каждом запросе GraphQL для учёта стоимости
+const rateLimitFragment = `rateLimit { cost limit remaining resetAt }`
+
+// GraphQLClient - реализация GitHubClient поверх GraphQL API v4
+type GraphQLClient struct {
+	endpoint   string
+	tokens     *TokenPool
+	httpClient *http.Client
+
+	maxRateLimitWait time.Duration
+	retryPolicy      RetryPolicy
+}
+
+// NewGraphQLClient создаёт клиент GraphQL API GitHub.
+// baseURL задаётся так же, как для NewHTTPClient: для GitHub Enterprise Server используется /api/graphql.
+func NewGraphQLClient(baseURL string, tokens *TokenPool, httpClient *http.Client) *GraphQLClient {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 15 * time.Second,
+		}
+	}
+	if tokens == nil {
+		tokens = NewTokenPool()
+	}
+
+	apiURL, enterprise := normalizeBaseURL(baseURL)
+	endpoint := apiURL + "/graphql"
+	if enterprise {
+		endpoint = strings.TrimSuffix(apiURL, enterpriseAPIPath) + "/api/graphql"
+	}
+
+	return &GraphQLClient{
+		endpoint:         endpoint,
+		tokens:           tokens,
+		httpClient:       httpClient,
+		maxRateLimitWait: DefaultMaxRateLimitWait,
+		retryPolicy:      DefaultRetryPolicy(),
+	}
+}
+
+// SetMaxRateLimitWait задаёт, сколько клиент может ждать сброса лимита, прежде чем вернуть ErrRateLimited
+func (c *GraphQLClient) SetMaxRateLimitWait(wait time.Duration) {
+	c.maxRateLimitWait = wait
+}
+
+// SetRetryPolicy задаёт политику повторных попыток
+func (c *GraphQLClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// graphqlConnection - страница связи GraphQL с курсорной пагинацией
+type graphqlConnection[T any] struct {
+	TotalCount int `json:"totalCount"`
+	PageInfo   struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []T `json:"nodes"`
+}
+
+// graphqlUser - узел пользователя в связях GraphQL
+type graphqlUser struct {
+	DatabaseID int64  `json:"databaseId"`
+	Login      string `json:"login"`
+}
+
+// user преобразует узел GraphQL в пользователя
+func (u graphqlUser) user() models.User {
+	return models.User{ID: u.DatabaseID, Login: u.Login}
+}
+
+// graphqlError - элемент массива errors ответа GraphQL
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Path    []any  `json:"path"` // Имена полей и индексы элементов списков
+}
+
+// graphqlErrorResource определяет по пути ошибки, какой объект не найден: user или repository
+func graphqlErrorResource(gqlErr graphqlError) string {
+	if len(gqlErr.Path) == 0 {
+		return ""
+	}
+	field, _ := gqlErr.Path[0].(string)
+	switch field {
+	case "repository":
+		return resourceKindRepository
+	case "user", "node", "follower", "followed":
+		return resourceKindUser
+	}
+	return ""
+}
+
+// GetUser получает пользователя по логину через GraphQL
+func (c *GraphQLClient) GetUser(ctx context.Context, login string) (models.User, error) {
+	var data struct {
+		User *graphqlUser `json:"user"`
+	}
+	query := `query($login: String!) {
+		user(login: $login) { login databaseId }
+		` + rateLimitFragment + `
+	}`
+	if err := c.query(ctx, query, map[string]any{"login": login}, &data); err != nil {
+		logger.Error("Failed to get user "+login+" via GraphQL", err)
+		return models.User{}, err
+	}
+	if data.User == nil {
+		return models.User{}, &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: "user not found", Resource: resourceKindUser}
+	}
+	return data.User.user(), nil
+}
+
+// GetUserByID получает пользователя по числовому ID через GraphQL.
+// Поиска по databaseId в схеме нет, поэтому запрашивается node с глобальным ID старого формата,
+// который GitHub по-прежнему принимает: base64("04:User" + ID).
+func (c *GraphQLClient) GetUserByID(ctx context.Context, id int64) (models.User, error) {
+	var data struct {
+		Node *graphqlUser `json:"node"`
+	}
+	query := `query($id: ID!) {
+		node(id: $id) { ... on User { login databaseId } }
+		` + rateLimitFragment + `
+	}`
+	nodeID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("04:User%d", id)))
+	if err := c.query(ctx, query, map[string]any{"id": nodeID}, &data); err != nil {
+		logger.Error(fmt.Sprintf("Failed to get user %d via GraphQL", id), err)
+		return models.User{}, err
+	}
+	if data.Node == nil || data.Node.DatabaseID != id {
+		return models.User{}, &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: "user not found", Resource: resourceKindUser}
+	}
+	return data.Node.user(), nil
+}
+
+// GetFollowers получает подписчиков пользователя через GraphQL
+func (c *GraphQLClient) GetFollowers(ctx context.Context, username string) ([]models.User, error) {
+	query := `query($login: String!, $after: String) {
+		user(login: $login) { followers(first: 100, after: $after) { totalCount pageInfo { hasNextPage endCursor } nodes { login databaseId } } }
+		` + rateLimitFragment + `
+	}`
+
+	var followers []models.User
+	err := paginateConnection(ctx, c, query, map[string]any{"login": username}, []string{"user", "followers"}, func(nodes []graphqlUser) bool {
+		for _, node := range nodes {
+			followers = append(followers, node.user())
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get followers for %s via GraphQL", username), err)
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Retrieved %d followers for %s from GitHub GraphQL", len(followers), username))
+	return followers, nil
+}
+
+// GetStargazers получает пользователей, поставивших звезду на репозиторий, через GraphQL
+func (c *GraphQLClient) GetStargazers(ctx context.Context, repository string) ([]models.User, error) {
+	var stargazers []models.User
+	err := c.scanStargazers(ctx, repository, func(stargazer models.User) bool {
+		stargazers = append(stargazers, stargazer)
+		return true
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get stargazers for %s via GraphQL", repository), err)
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Retrieved %d stargazers for %s from GitHub GraphQL", len(stargazers), repository))
+	return stargazers, nil
+}
+
+// CheckStarred проверяет звезду, просматривая звёзды пользователя
+func (c *GraphQLClient) CheckStarred(ctx context.Context, username, repository string) (bool, error) {
+	query := `query($login: String!, $after: String) {
+		user(login: $login) { starredRepositories(first: 100, after: $after) { totalCount pageInfo { hasNextPage endCursor } nodes { nameWithOwner } } }
+		` + rateLimitFragment + `
+	}`
+
+	hasStar := false
+	err := paginateConnection(ctx, c, query, map[string]any{"login": username}, []string{"user", "starredRepositories"}, func(nodes []struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	}) bool {
+		for _, node := range nodes {
+			if strings.EqualFold(node.NameWithOwner, repository) {
+				hasStar = true
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check starred repositories of user %s via GraphQL", username), err)
+		return false, err
+	}
+	return hasStar, nil
+}
+
+// IsFollowing проверяет подписку. В GraphQL нет запроса для произвольной пары пользователей,
+// поэтому сначала одним запросом сравниваются размеры списков и просматривается меньший.
+func (c *GraphQLClient) IsFollowing(ctx context.Context, follower, username string) (bool, error) {
+	var counts struct {
+		Follower *struct {
+			Following struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"following"`
+		} `json:"follower"`
+		Followed *struct {
+			Followers struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"followers"`
+		} `json:"followed"`
+	}
+	countQuery := `query($follower: String!, $followed: String!) {
+		follower: user(login: $follower) { following { totalCount } }
+		followed: user(login: $followed) { followers { totalCount } }
+		` + rateLimitFragment + `
+	}`
+	if err := c.query(ctx, countQuery, map[string]any{"follower": follower, "followed": username}, &counts); err != nil {
+		logger.Error(fmt.Sprintf("Failed to check if %s follows %s via GraphQL", follower, username), err)
+		return false, err
+	}
+	if counts.Follower == nil || counts.Followed == nil {
+		return false, &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: "user not found", Resource: resourceKindUser}
+	}
+
+	// Просматриваем подписки follower или подписчиков username - что короче
+	login, connection, match := follower, "following", username
+	if counts.Followed.Followers.TotalCount < counts.Follower.Following.TotalCount {
+		login, connection, match = username, "followers", follower
+	}
+	query := fmt.Sprintf(`query($login: String!, $after: String) {
+		user(login: $login) { %s(first: 100, after: $after) { totalCount pageInfo { hasNextPage endCursor } nodes { login databaseId } } }
+		%s
+	}`, connection, rateLimitFragment)
+
+	isFollowing := false
+	err := paginateConnection(ctx, c, query, map[string]any{"login": login}, []string{"user", connection}, func(nodes []graphqlUser) bool {
+		for _, node := range nodes {
+			if strings.EqualFold(node.Login, match) {
+				isFollowing = true
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check if %s follows %s via GraphQL", follower, username), err)
+		return false, err
+	}
+	return isFollowing, nil
+}
+
+// CountStargazers возвращает количество звёзд репозитория
+func (c *GraphQLClient) CountStargazers(ctx context.Context, repository string) (int, error) {
+	owner, name, err := splitRepository(repository)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Repository *struct {
+			StargazerCount int `json:"stargazerCount"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) { stargazerCount }
+		` + rateLimitFragment + `
+	}`
+	if err := c.query(ctx, query, map[string]any{"owner": owner, "name": name}, &data); err != nil {
+		return 0, err
+	}
+	if data.Repository == nil {
+		return 0, &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: "repository not found", Resource: resourceKindRepository}
+	}
+	return data.Repository.StargazerCount, nil
+}
+
+// CountStarred возвращает количество звёзд пользователя
+func (c *GraphQLClient) CountStarred(ctx context.Context, username string) (int, error) {
+	var data struct {
+		User *struct {
+			StarredRepositories struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"starredRepositories"`
+		} `json:"user"`
+	}
+	query := `query($login: String!) {
+		user(login: $login) { starredRepositories { totalCount } }
+		` + rateLimitFragment + `
+	}`
+	if err := c.query(ctx, query, map[string]any{"login": username}, &data); err != nil {
+		return 0, err
+	}
+	if data.User == nil {
+		return 0, &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: "user not found", Resource: resourceKindUser}
+	}
+	return data.User.StarredRepositories.TotalCount, nil
+}
+
+// RateLimit возвращает состояние лимита GraphQL API каждого токена
+func (c *GraphQLClient) RateLimit(ctx context.Context) ([]TokenRateLimit, error) {
+	return c.tokens.checkEachToken(ctx, c.tokenRateLimit)
+}
+
+// tokenRateLimit возвращает состояние лимита GraphQL API одного токена
+func (c *GraphQLClient) tokenRateLimit(ctx context.Context, token *pooledToken) (RateLimitUsage, error) {
+	var data struct {
+		RateLimit *struct {
+			Limit     int       `json:"limit"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if err := c.makeGraphQLRequest(ctx, `query { `+rateLimitFragment+` }`, nil, &data, token); err != nil {
+		logger.Error("Failed to get rate limit via GraphQL", err, "token", token.name())
+		return RateLimitUsage{}, err
+	}
+	// На GitHub Enterprise Server с отключённым лимитом поле пустое
+	if data.RateLimit == nil {
+		return RateLimitUsage{}, ErrRateLimitDisabled
+	}
+	return RateLimitUsage{Limit: data.RateLimit.Limit, Remaining: data.RateLimit.Remaining, Reset: data.RateLimit.ResetAt}, nil
+}
+
+// scanStargazers обходит звёзды репозитория, пока visit возвращает true
+func (c *GraphQLClient) scanStargazers(ctx context.Context, repository string, visit func(stargazer models.User) bool) error {
+	owner, name, err := splitRepository(repository)
+	if err != nil {
+		return err
+	}
+
+	query := `query($owner: String!, $name: String!, $after: String) {
+		repository(owner: $owner, name: $name) { stargazers(first: 100, after: $after) { totalCount pageInfo { hasNextPage endCursor } nodes { login databaseId } } }
+		` + rateLimitFragment + `
+	}`
+	return paginateConnection(ctx, c, query, map[string]any{"owner": owner, "name": name}, []string{"repository", "stargazers"}, func(nodes []graphqlUser) bool {
+		for _, node := range nodes {
+			if !visit(node.user()) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// paginateConnection обходит связь GraphQL по курсорам. path - путь от data до связи, например user.followers.
+// Обход прекращается досрочно, если visit вернёт false.
+func paginateConnection[T any](ctx context.Context, c *GraphQLClient, query string, vars map[string]any, path []string, visit func(nodes []T) bool) error {
+	var after any
+	for page := 1; ; page++ {
+		vars["after"] = after
+
+		var data map[string]json.RawMessage
+		if err := c.query(ctx, query, vars, &data); err != nil {
+			return err
+		}
+
+		var connection graphqlConnection[T]
+		if err := decodePath(data, path, &connection); err != nil {
+			return err
+		}
+
+		metrics.GitHubPages.WithLabelValues("graphql:" + strings.Join(path, ".")).Inc()
+		logger.Debug(fmt.Sprintf("Fetched GraphQL page %d of %s", page, strings.Join(path, ".")), "total_count", connection.TotalCount)
+		if !visit(connection.Nodes) || !connection.PageInfo.HasNextPage {
+			return nil
+		}
+		after = connection.PageInfo.EndCursor
+	}
+}
+
+// decodePath спускается по вложенным объектам data и декодирует найденное значение в out.
+// null на пути означает отсутствующий объект и возвращается как 404.
+func decodePath(data map[string]json.RawMessage, path []string, out any) error {
+	current := data
+	for i, key := range path {
+		raw, ok := current[key]
+		if !ok || string(raw) == "null" {
+			return &APIError{StatusCode: http.StatusNotFound, URL: strings.Join(path[:i+1], "."), Message: "not found", Resource: path[0]}
+		}
+		if i == len(path)-1 {
+			return json.Unmarshal(raw, out)
+		}
+		current = nil
+		if err := json.Unmarshal(raw, &current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query выполняет запрос GraphQL с повторными попытками и декодирует поле data в out
+func (c *GraphQLClient) query(ctx context.Context, query string, vars map[string]any, out any) error {
+	return doWithRetries(ctx, c.tokens, resourceGraphQL, c.retryPolicy, c.maxRateLimitWait, c.endpoint, func(token *pooledToken) error {
+		return c.makeGraphQLRequest(ctx, query, vars, out, token)
+	})
+}
+
+// makeGraphQLRequest выполняет один запрос GraphQL и учитывает его стоимость
+func (c *GraphQLClient) makeGraphQLRequest(ctx context.Context, query string, vars map[string]any, out any, token *pooledToken) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		logger.Error("Error creating GitHub GraphQL request", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	credential, err := token.credential(ctx)
+	if err != nil {
+		logger.Error("Error obtaining GitHub credential", err)
+		return err
+	}
+	if credential != "" {
+		req.Header.Set("Authorization", "bearer "+credential)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		logger.Error("Error making GitHub GraphQL request", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	token.updateLimits(resourceGraphQL, resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if rlErr := parseRateLimitError(resp, body); rlErr != nil {
+			return rlErr
+		}
+		return newAPIError(resp.StatusCode, c.endpoint, string(body))
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphqlError  `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		logger.Error("Error decoding GitHub GraphQL response", err)
+		return err
+	}
+
+	c.recordCost(token, result.Data)
+
+	if len(result.Errors) > 0 {
+		return c.graphqlErrorToError(token, result.Errors)
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// recordCost обновляет состояние лимита и суммарную стоимость по полю rateLimit ответа
+func (c *GraphQLClient) recordCost(token *pooledToken, data json.RawMessage) {
+	var payload struct {
+		RateLimit *struct {
+			Cost      int       `json:"cost"`
+			Limit     int       `json:"limit"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil || payload.RateLimit == nil {
+		return
+	}
+
+	rateLimit := payload.RateLimit
+	token.limit(resourceGraphQL).set(rateLimit.Remaining, rateLimit.Limit, rateLimit.ResetAt)
+	metrics.GitHubRateLimitRemaining.WithLabelValues(token.name(), resourceGraphQL).Set(float64(rateLimit.Remaining))
+	metrics.GitHubGraphQLCost.WithLabelValues(token.name()).Add(float64(rateLimit.Cost))
+	logger.Info("GitHub GraphQL query cost", "token", token.name(), "cost", rateLimit.Cost, "remaining", rateLimit.Remaining)
+}
+
+// graphqlErrorToError преобразует ошибки GraphQL в ошибки, которые понимает остальной код сервиса.
+// Ошибки без известного типа (невалидный запрос, неверные аргументы) повтором не исправить,
+// поэтому они возвращаются как ответ 422, а не как недоступность GitHub.
+func (c *GraphQLClient) graphqlErrorToError(token *pooledToken, gqlErrors []graphqlError) error {
+	messages := make([]string, 0, len(gqlErrors))
+	for _, gqlErr := range gqlErrors {
+		switch gqlErr.Type {
+		case "NOT_FOUND":
+			return &APIError{StatusCode: http.StatusNotFound, URL: c.endpoint, Message: gqlErr.Message, Resource: graphqlErrorResource(gqlErr)}
+		case "FORBIDDEN":
+			return &APIError{StatusCode: http.StatusForbidden, URL: c.endpoint, Message: gqlErr.Message}
+		case "RATE_LIMITED":
+			// Время сброса известно из поля rateLimit, если GitHub успел его вернуть
+			if _, _, reset := token.limit(resourceGraphQL).snapshot(); reset.After(time.Now()) {
+				return &RateLimitError{Reset: reset}
+			}
+			return &RateLimitError{Reset: time.Now().Add(secondaryLimitPause), Secondary: true}
+		}
+		messages = append(messages, gqlErr.Message)
+	}
+	return &APIError{StatusCode: http.StatusUnprocessableEntity, URL: c.endpoint, Message: strings.Join(messages, "; ")}
+}
+
+// splitRepository разбивает owner/name на части
+func splitRepository(repository string) (string, string, error) {
+	owner, name, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || name == "" {
+		return "", "", fmt.Errorf("%w: repository %q, expected owner/name", ErrInvalidInput, repository)
+	}
+	return owner, name, nil
+}
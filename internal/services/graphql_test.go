@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGraphQLErrorResource(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "repository", body: `{"type":"NOT_FOUND","path":["repository"]}`, want: resourceKindRepository},
+		{name: "user", body: `{"type":"NOT_FOUND","path":["user"]}`, want: resourceKindUser},
+		{name: "node by id", body: `{"type":"NOT_FOUND","path":["node"]}`, want: resourceKindUser},
+		{name: "list index in path", body: `{"type":"NOT_FOUND","path":["user","followers","nodes",3,"login"]}`, want: resourceKindUser},
+		{name: "index first", body: `{"type":"NOT_FOUND","path":[0,"repository"]}`, want: ""},
+		{name: "unknown field", body: `{"type":"NOT_FOUND","path":["viewer"]}`, want: ""},
+		{name: "no path", body: `{"type":"NOT_FOUND"}`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gqlErr graphqlError
+			if err := json.Unmarshal([]byte(tt.body), &gqlErr); err != nil {
+				t.Fatalf("unmarshal %s: %v", tt.body, err)
+			}
+			if got := graphqlErrorResource(gqlErr); got != tt.want {
+				t.Errorf("graphqlErrorResource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибки GraphQL, которые не исправить повтором, не должны повторяться и выдаваться за недоступность GitHub
+func TestGraphQLErrorsAreNotRetried(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "forbidden",
+			body:       `{"data":{"repository":null},"errors":[{"type":"FORBIDDEN","path":["repository"],"message":"Resource not accessible by integration"}]}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid query",
+			body:       `{"errors":[{"message":"Field 'stargazer' doesn't exist on type 'Repository'"}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "invalid argument",
+			body:       `{"data":null,"errors":[{"type":"ARGUMENT_LIMIT","message":"first must be at most 100"}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewGraphQLClient(server.URL, NewTokenPool("ghp_test"), nil)
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+			_, err := client.CountStargazers(context.Background(), "owner/repo")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+				t.Fatalf("got error %v, want APIError with status %d", err, tt.wantStatus)
+			}
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				t.Errorf("error %v reported as upstream unavailable", err)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("got %d requests, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"gh-checker/internal/database"
+	"gh-checker/internal/lib/logger"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain открывает базу во временном каталоге: сервисы кэшируют результаты через пакет database
+func TestMain(m *testing.M) {
+	if err := logger.InitializeLogger(logger.LogConfig{FilePath: os.DevNull}); err != nil {
+		panic(err)
+	}
+	dir, err := os.MkdirTemp("", "gh-checker-services")
+	if err != nil {
+		panic(err)
+	}
+	if err := database.InitDB(filepath.Join(dir, "test.db")); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	database.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
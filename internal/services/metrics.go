@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gh-checker/internal/lib/metrics"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сегменты пути, после которых идут логины, ID и имена репозиториев - их заменяем шаблоном
+var endpointParams = map[string][]string{
+	"users":         {"{user}"},
+	"user":          {"{id}"},
+	"repos":         {"{owner}", "{repo}"},
+	"following":     {"{target}"},
+	"installations": {"{id}"},
+}
+
+// endpointLabel превращает адрес запроса к GitHub в шаблон эндпоинта для метрик, например /users/{user}/followers
+func endpointLabel(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "unknown"
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(parsed.Path, enterpriseAPIPath), "/"), "/")
+	for i := 0; i < len(segments); i++ {
+		for j, param := range endpointParams[segments[i]] {
+			if i+1+j < len(segments) {
+				segments[i+1+j] = param
+			}
+		}
+		i += len(endpointParams[segments[i]])
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// githubStatus описывает результат попытки запроса к GitHub для метрик
+func githubStatus(err error) string {
+	var apiErr *APIError
+	var rlErr *RateLimitError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &rlErr):
+		return "rate_limited"
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	return "error"
+}
+
+// observeGitHubRequest учитывает одну попытку запроса к GitHub
+func observeGitHubRequest(target string, attempt int, start time.Time, err error) {
+	endpoint := endpointLabel(target)
+	metrics.GitHubRequests.WithLabelValues(endpoint, githubStatus(err), strconv.Itoa(attempt)).Inc()
+	metrics.GitHubRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
+
+// observeCache учитывает обращение к кэшу подписчиков или звёзд
+func observeCache(cache, result string) {
+	metrics.CacheLookups.WithLabelValues(cache, result).Inc()
+}
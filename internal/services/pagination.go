@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// pageIterator обходит страницы списка GitHub API, следуя заголовку Link (RFC 5988)
+type pageIterator struct {
+	client   *HTTPClient
+	next     string // Адрес следующей страницы, пусто - страниц больше нет
+	page     int    // Номер последней загруженной страницы
+	lastPage int    // Номер последней страницы из rel="last", 0 - неизвестен
+}
+
+// newPageIterator создаёт итератор по списку, начиная с первой страницы
+func newPageIterator(client *HTTPClient, listURL string) *pageIterator {
+	return &pageIterator{
+		client: client,
+		next:   withQuery(listURL, "per_page", strconv.Itoa(maxFollowersPerPage)),
+	}
+}
+
+// HasNext сообщает, есть ли ещё непрочитанные страницы
+func (it *pageIterator) HasNext() bool {
+	return it.next != ""
+}
+
+// LastPage возвращает номер последней страницы из rel="last" или 0, если он ещё неизвестен
+func (it *pageIterator) LastPage() int {
+	return it.lastPage
+}
+
+// Next загружает очередную страницу. Вызывающий обязан закрыть тело ответа.
+func (it *pageIterator) Next(ctx context.Context) (*http.Response, error) {
+	pageURL := it.next
+	resp, err := it.client.makeGitHubAPIRequestWithRetries(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	it.page++
+	metrics.GitHubPages.WithLabelValues(endpointLabel(pageURL)).Inc()
+	links := parseLinkHeader(resp.Header.Get("Link"))
+	it.next = links["next"]
+	if last, ok := links["last"]; ok {
+		it.lastPage = pageNumber(last)
+	} else if it.next == "" {
+		// На последней странице rel="last" не приходит
+		it.lastPage = it.page
+	}
+
+	logger.Debug(fmt.Sprintf("Fetched page %d of %s", it.page, pageURL), "last_page", it.lastPage)
+	return resp, nil
+}
+
+// forEachPage обходит страницы итератора, декодируя каждую в []T.
+// Обход прекращается досрочно, если visit вернёт false.
+func forEachPage[T any](ctx context.Context, it *pageIterator, visit func(items []T) bool) error {
+	for it.HasNext() {
+		items, err := decodePage[T](ctx, it)
+		if err != nil {
+			return err
+		}
+		if !visit(items) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// fetchAllPages загружает все страницы списка в исходном порядке.
+// Если после первой страницы известен номер последней, остальные загружаются параллельно,
+// не более concurrency запросов одновременно; первая же ошибка отменяет все незавершённые запросы.
+func fetchAllPages[T any](ctx context.Context, it *pageIterator, concurrency int) ([][]T, error) {
+	first, err := decodePage[T](ctx, it)
+	if err != nil {
+		return nil, err
+	}
+	pages := [][]T{first}
+
+	lastPage := it.LastPage()
+	if concurrency <= 1 || !it.HasNext() || lastPage <= it.page+1 {
+		err := forEachPage(ctx, it, func(items []T) bool {
+			pages = append(pages, items)
+			return true
+		})
+		return pages, err
+	}
+
+	logger.Info(fmt.Sprintf("Fetching pages %d-%d concurrently", it.page+1, lastPage), "concurrency", concurrency)
+
+	// Адреса остальных страниц строим из rel="next", меняя только номер страницы
+	template := it.next
+	rest := make([][]T, lastPage-it.page)
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+	for i := range rest {
+		pageURL := withQuery(template, "page", strconv.Itoa(it.page+1+i))
+		group.Go(func() error {
+			items, err := decodeResponse[T](it.client.makeGitHubAPIRequestWithRetries(groupCtx, pageURL))
+			if err != nil {
+				return err
+			}
+			metrics.GitHubPages.WithLabelValues(endpointLabel(pageURL)).Inc()
+			rest[i] = items
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	it.page = lastPage
+	it.next = ""
+	return append(pages, rest...), nil
+}
+
+// decodePage загружает очередную страницу итератора и декодирует её в []T
+func decodePage[T any](ctx context.Context, it *pageIterator) ([]T, error) {
+	return decodeResponse[T](it.Next(ctx))
+}
+
+// decodeResponse декодирует страницу списка в []T и закрывает тело ответа
+func decodeResponse[T any](resp *http.Response, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var items []T
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		logger.Error("Error decoding page from GitHub for "+resp.Request.URL.String(), err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// parseLinkHeader разбирает заголовок Link вида `<url>; rel="next", <url>; rel="last"` в карту rel -> url
+func parseLinkHeader(header string) map[string]string {
+	links := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		segments := strings.Split(strings.TrimSpace(part), ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		target = target[1 : len(target)-1]
+
+		for _, param := range segments[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.TrimSpace(key) != "rel" {
+				continue
+			}
+			// rel может содержать несколько значений через пробел
+			for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+				links[rel] = target
+			}
+		}
+	}
+	return links
+}
+
+// pageNumber возвращает значение параметра page из адреса страницы или 0
+func pageNumber(pageURL string) int {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return 0
+	}
+	page, err := strconv.Atoi(parsed.Query().Get("page"))
+	if err != nil {
+		return 0
+	}
+	return page
+}
+
+// withQuery возвращает адрес с установленным параметром запроса
+func withQuery(rawURL, key, value string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := parsed.Query()
+	query.Set(key, value)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
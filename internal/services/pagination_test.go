@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{
+			name:   "empty",
+			header: "",
+			want:   map[string]string{},
+		},
+		{
+			name:   "next and last",
+			header: `<https://api.github.com/user/1/followers?per_page=100&page=2>; rel="next", <https://api.github.com/user/1/followers?per_page=100&page=5>; rel="last"`,
+			want: map[string]string{
+				"next": "https://api.github.com/user/1/followers?per_page=100&page=2",
+				"last": "https://api.github.com/user/1/followers?per_page=100&page=5",
+			},
+		},
+		{
+			name:   "several rel values",
+			header: `<https://api.github.com/x?page=3>; rel="next last"`,
+			want: map[string]string{
+				"next": "https://api.github.com/x?page=3",
+				"last": "https://api.github.com/x?page=3",
+			},
+		},
+		{
+			name:   "extra parameters and spacing",
+			header: ` <https://api.github.com/x?page=1>;title="first" ;  rel=first `,
+			want:   map[string]string{"first": "https://api.github.com/x?page=1"},
+		},
+		{
+			name:   "malformed parts are skipped",
+			header: `https://api.github.com/x?page=2; rel="next", <https://api.github.com/x?page=4>, <https://api.github.com/x?page=1>; rel="prev"`,
+			want:   map[string]string{"prev": "https://api.github.com/x?page=1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinkHeader(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinkHeader(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageNumber(t *testing.T) {
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{url: "https://api.github.com/user/1/followers?per_page=100&page=7", want: 7},
+		{url: "https://api.github.com/user/1/followers?page=12&per_page=100", want: 12},
+		{url: "https://api.github.com/user/1/followers?per_page=100", want: 0},
+		{url: "https://api.github.com/user/1/followers?page=last", want: 0},
+		{url: "://bad", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := pageNumber(tt.url); got != tt.want {
+				t.Errorf("pageNumber(%q) = %d, want %d", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+// pagedServer отдаёт список из pages страниц, в каждой одно число - номер страницы.
+// Страница failPage отвечает ошибкой 404.
+func pagedServer(t *testing.T, pages int, withLast bool, failPage int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page := 1
+		if raw := r.URL.Query().Get("page"); raw != "" {
+			page, _ = strconv.Atoi(raw)
+		}
+		if page == failPage {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+
+		pageURL := func(n int) string {
+			return fmt.Sprintf("%s%s?per_page=100&page=%d", server.URL, r.URL.Path, n)
+		}
+		var links []string
+		if page < pages {
+			links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)))
+			if withLast {
+				links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(pages)))
+			}
+		}
+		if len(links) > 0 {
+			w.Header().Set("Link", strings.Join(links, ", "))
+		}
+		fmt.Fprintf(w, "[%d]", page)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestFetchAllPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		pages       int
+		withLast    bool
+		concurrency int
+		failPage    int
+		wantErr     error
+	}{
+		{name: "single page", pages: 1, withLast: true, concurrency: 4},
+		{name: "sequential", pages: 5, withLast: true, concurrency: 1},
+		{name: "concurrent", pages: 7, withLast: true, concurrency: 3},
+		{name: "without last link", pages: 4, withLast: false, concurrency: 3},
+		{name: "concurrent error", pages: 6, withLast: true, concurrency: 3, failPage: 4, wantErr: ErrNotFound},
+		{name: "sequential error", pages: 3, withLast: false, concurrency: 1, failPage: 2, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := pagedServer(t, tt.pages, tt.withLast, tt.failPage)
+			client := NewHTTPClient(server.URL, nil, nil)
+			it := newPageIterator(client, client.baseURL+"/user/1/followers")
+
+			pages, err := fetchAllPages[int](context.Background(), it, tt.concurrency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([][]int, tt.pages)
+			for i := range want {
+				want[i] = []int{i + 1}
+			}
+			if !reflect.DeepEqual(pages, want) {
+				t.Errorf("got pages %v, want %v", pages, want)
+			}
+			if got := int(requests.Load()); got != tt.pages {
+				t.Errorf("got %d requests, want %d", got, tt.pages)
+			}
+			if it.HasNext() {
+				t.Error("iterator still has pages")
+			}
+		})
+	}
+}
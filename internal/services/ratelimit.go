@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited - ошибка исчерпания лимита запросов к GitHub API
+var ErrRateLimited = errors.New("github api rate limit exceeded")
+
+// ErrRateLimitDisabled - лимит запросов отключён (GitHub Enterprise Server)
+var ErrRateLimitDisabled = errors.New("github api rate limiting is disabled")
+
+// secondaryLimitPause - пауза после вторичного лимита, если GitHub не прислал Retry-After
+const secondaryLimitPause = time.Minute
+
+// RateLimitError описывает срабатывание первичного или вторичного лимита GitHub
+type RateLimitError struct {
+	Reset     time.Time // Момент, после которого запросы снова разрешены
+	Secondary bool      // Вторичный (abuse) лимит, а не исчерпание часовой квоты
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "primary"
+	if e.Secondary {
+		kind = "secondary"
+	}
+	return fmt.Sprintf("%s: %s limit, resets at %s", ErrRateLimited, kind, e.Reset.Format(time.RFC3339))
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrRateLimited)
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter возвращает время ожидания до сброса лимита
+func (e *RateLimitError) RetryAfter() time.Duration {
+	wait := time.Until(e.Reset)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// rateLimitState хранит последнее известное состояние лимитов GitHub
+type rateLimitState struct {
+	mu           sync.Mutex
+	limit        int
+	remaining    int
+	reset        time.Time
+	known        bool
+	blockedUntil time.Time // Время окончания вторичного лимита
+}
+
+// update обновляет состояние по заголовкам X-RateLimit-* ответа
+func (s *rateLimitState) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remaining = remaining
+	s.known = true
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		s.limit = limit
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		s.reset = time.Unix(reset, 0)
+	}
+
+	logger.Debug("GitHub rate limit updated", "remaining", s.remaining, "limit", s.limit, "reset", s.reset)
+}
+
+// set обновляет состояние по данным, полученным не из заголовков (например, из поля rateLimit GraphQL)
+func (s *rateLimitState) set(remaining, limit int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remaining = remaining
+	s.limit = limit
+	s.reset = reset
+	s.known = true
+}
+
+// block запоминает сработавший лимит, чтобы не отправлять запросы до его сброса
+func (s *rateLimitState) block(rlErr *RateLimitError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rlErr.Secondary {
+		s.blockedUntil = rlErr.Reset
+		return
+	}
+	s.remaining = 0
+	s.reset = rlErr.Reset
+	s.known = true
+}
+
+// check возвращает RateLimitError, если по известному состоянию запрос заведомо будет отклонён
+func (s *rateLimitState) check() *RateLimitError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(s.blockedUntil) {
+		return &RateLimitError{Reset: s.blockedUntil, Secondary: true}
+	}
+	if s.known && s.remaining <= 0 && now.Before(s.reset) {
+		return &RateLimitError{Reset: s.reset}
+	}
+	return nil
+}
+
+// snapshot возвращает остаток, размер и время сброса лимита.
+// Пока GitHub не сообщил остаток, возвращается defaultTokenQuota.
+func (s *rateLimitState) snapshot() (int, int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.known {
+		return defaultTokenQuota, s.limit, s.reset
+	}
+	// Окно лимита уже сброшено, квота восстановлена
+	if time.Now().After(s.reset) && s.limit > 0 {
+		return s.limit, s.limit, s.reset
+	}
+	return s.remaining, s.limit, s.reset
+}
+
+// parseRateLimitError определяет, отклонён ли запрос из-за лимита, и возвращает описание лимита
+func parseRateLimitError(resp *http.Response, body []byte) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	// Retry-After присылается при вторичных лимитах
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return &RateLimitError{Reset: time.Now().Add(time.Duration(retryAfter) * time.Second), Secondary: true}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset := time.Now().Add(secondaryLimitPause)
+		if epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(epoch, 0)
+		}
+		return &RateLimitError{Reset: reset}
+	}
+
+	if strings.Contains(strings.ToLower(string(body)), "secondary rate limit") || resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{Reset: time.Now().Add(secondaryLimitPause), Secondary: true}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitError(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name          string
+		status        int
+		header        map[string]string
+		body          string
+		wantNil       bool
+		wantSecondary bool
+		wantReset     time.Duration // Ожидаемое время до сброса, если не задан wantResetAt
+		wantResetAt   time.Time
+	}{
+		{
+			name:        "primary limit",
+			status:      http.StatusForbidden,
+			header:      map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)},
+			body:        `{"message":"API rate limit exceeded"}`,
+			wantResetAt: reset,
+		},
+		{
+			name:      "primary limit without reset",
+			status:    http.StatusForbidden,
+			header:    map[string]string{"X-RateLimit-Remaining": "0"},
+			wantReset: secondaryLimitPause,
+		},
+		{
+			name:          "retry after",
+			status:        http.StatusForbidden,
+			header:        map[string]string{"Retry-After": "120", "X-RateLimit-Remaining": "0"},
+			wantSecondary: true,
+			wantReset:     2 * time.Minute,
+		},
+		{
+			name:          "secondary limit message",
+			status:        http.StatusForbidden,
+			header:        map[string]string{"X-RateLimit-Remaining": "4000"},
+			body:          `{"message":"You have exceeded a secondary rate limit."}`,
+			wantSecondary: true,
+			wantReset:     secondaryLimitPause,
+		},
+		{
+			name:          "too many requests",
+			status:        http.StatusTooManyRequests,
+			wantSecondary: true,
+			wantReset:     secondaryLimitPause,
+		},
+		{
+			name:    "forbidden without limit",
+			status:  http.StatusForbidden,
+			header:  map[string]string{"X-RateLimit-Remaining": "4000"},
+			body:    `{"message":"Resource not accessible by integration"}`,
+			wantNil: true,
+		},
+		{
+			name:    "other status",
+			status:  http.StatusNotFound,
+			header:  map[string]string{"X-RateLimit-Remaining": "0"},
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: make(http.Header)}
+			for key, value := range tt.header {
+				resp.Header.Set(key, value)
+			}
+
+			got := parseRateLimitError(resp, []byte(tt.body))
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("got %v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("got nil, want RateLimitError")
+			}
+			if got.Secondary != tt.wantSecondary {
+				t.Errorf("Secondary = %v, want %v", got.Secondary, tt.wantSecondary)
+			}
+
+			if !tt.wantResetAt.IsZero() {
+				if !got.Reset.Equal(tt.wantResetAt) {
+					t.Errorf("Reset = %s, want %s", got.Reset, tt.wantResetAt)
+				}
+				return
+			}
+			if wait := time.Until(got.Reset); wait > tt.wantReset || wait < tt.wantReset-5*time.Second {
+				t.Errorf("Reset in %s, want about %s", wait, tt.wantReset)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gh-checker/internal/database"
+	"gh-checker/internal/lib/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CachedResponse - сохранённый ответ GitHub API для условных запросов
+type CachedResponse struct {
+	ETag   string
+	Header http.Header
+	Body   []byte
+}
+
+// ResponseCache хранит ответы GitHub API по URL, чтобы отправлять If-None-Match
+type ResponseCache interface {
+	// Get возвращает сохранённый ответ или false, если его нет
+	Get(ctx context.Context, url string) (CachedResponse, bool)
+	// Put сохраняет ответ для URL
+	Put(ctx context.Context, url string, resp CachedResponse)
+	// Touch отмечает, что GitHub подтвердил сохранённый ответ (304 Not Modified)
+	Touch(ctx context.Context, url string)
+}
+
+// Значения по умолчанию для вытеснения ответов из http_cache
+const (
+	DefaultResponseCacheTTL        = 7 * 24 * time.Hour
+	DefaultResponseCacheMaxEntries = 50000
+)
+
+// responseCachePruneInterval - как часто Put удаляет устаревшие ответы
+const responseCachePruneInterval = 10 * time.Minute
+
+// DatabaseResponseCache хранит ответы в таблице http_cache SQLite.
+// Ответы, которые не подтверждались дольше ttl, и самые старые сверх maxEntries периодически удаляются.
+type DatabaseResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewDatabaseResponseCache создаёт кэш ответов в базе. Нулевые ttl и maxEntries заменяются значениями по умолчанию.
+func NewDatabaseResponseCache(ttl time.Duration, maxEntries int) *DatabaseResponseCache {
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultResponseCacheMaxEntries
+	}
+	return &DatabaseResponseCache{ttl: ttl, maxEntries: maxEntries}
+}
+
+// Get возвращает ответ из таблицы http_cache
+func (c *DatabaseResponseCache) Get(ctx context.Context, url string) (CachedResponse, bool) {
+	etag, headers, body, err := database.GetCachedResponse(ctx, url)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.Error("Error reading cached response for "+url, err)
+		}
+		return CachedResponse{}, false
+	}
+
+	header := http.Header{}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &header); err != nil {
+			logger.Error("Error decoding cached headers for "+url, err)
+			return CachedResponse{}, false
+		}
+	}
+
+	return CachedResponse{ETag: etag, Header: header, Body: body}, true
+}
+
+// Put сохраняет ответ в таблицу http_cache. Ошибки только логируются: кэш не должен ломать запрос.
+func (c *DatabaseResponseCache) Put(ctx context.Context, url string, resp CachedResponse) {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		logger.Error("Error encoding headers for cache of "+url, err)
+		return
+	}
+
+	if err := database.SaveCachedResponse(ctx, url, resp.ETag, string(headers), resp.Body); err != nil {
+		logger.Error("Error caching response for "+url, err)
+	}
+	c.prune(ctx)
+}
+
+// Touch продлевает жизнь ответа, подтверждённого GitHub
+func (c *DatabaseResponseCache) Touch(ctx context.Context, url string) {
+	if err := database.TouchCachedResponse(ctx, url); err != nil {
+		logger.Error("Error touching cached response for "+url, err)
+	}
+}
+
+// prune удаляет устаревшие ответы не чаще responseCachePruneInterval
+func (c *DatabaseResponseCache) prune(ctx context.Context) {
+	c.mu.Lock()
+	if time.Since(c.lastPruned) < responseCachePruneInterval {
+		c.mu.Unlock()
+		return
+	}
+	c.lastPruned = time.Now()
+	c.mu.Unlock()
+
+	if _, err := database.PruneCachedResponses(ctx, time.Now().Add(-c.ttl), c.maxEntries); err != nil {
+		logger.Error("Error pruning cached responses", err)
+	}
+}
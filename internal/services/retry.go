@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy описывает повторные попытки запросов к GitHub с экспоненциальной задержкой
+type RetryPolicy struct {
+	MaxAttempts int           // Общее число попыток, включая первую
+	BaseDelay   time.Duration // Задержка перед второй попыткой, далее удваивается
+	MaxDelay    time.Duration // Верхняя граница задержки
+	Jitter      float64       // Доля задержки (0..1), на которую она случайно уменьшается
+}
+
+// DefaultRetryPolicy возвращает политику повторов по умолчанию
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// delay возвращает задержку перед попыткой attempt+1
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// isRetryable сообщает, может ли повтор запроса дать другой результат:
+// сетевые ошибки, ответы 5xx и лимиты запросов - да, остальные ответы GitHub - нет
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var rlErr *RateLimitError
+	if errors.As(err, &rlErr) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Ошибки соединения и чтения ответа
+	return true
+}
+
+// doWithRetries выполняет запрос к GitHub с повторными попытками по политике policy.
+// Для каждой попытки из пула выбирается токен с наибольшим запасом квоты по ресурсу.
+func doWithRetries(ctx context.Context, tokens *TokenPool, resource string, policy RetryPolicy, maxRateLimitWait time.Duration, target string, do func(token *pooledToken) error) error {
+	var err error
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// Не тратим запрос, если лимит всех токенов заведомо исчерпан
+		token, acquireErr := tokens.acquire(ctx, resource, maxRateLimitWait)
+		if acquireErr != nil {
+			return acquireErr
+		}
+
+		logger.Info(fmt.Sprintf("Attempt %d to make GitHub API request to %s", attempt, target), "token", token.name())
+		start := time.Now()
+		err = do(token)
+		observeGitHubRequest(target, attempt, start, err)
+		if err == nil {
+			return nil
+		}
+		logger.Error(fmt.Sprintf("Error making GitHub API request to %s (attempt %d)", target, attempt), err)
+
+		// Клиент отключился или истёк срок запроса: повторять бессмысленно
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Лимит токена исчерпан: запоминаем это, следующая попытка возьмёт другой токен или дождётся сброса
+		var rlErr *RateLimitError
+		isRateLimited := errors.As(err, &rlErr)
+		if isRateLimited {
+			token.limit(resource).block(rlErr)
+		}
+
+		// Токен отклонён: выводим его из ротации и пробуем другой, если он есть
+		if errors.Is(err, ErrUnauthorized) && !token.anonymous() {
+			tokens.retire(token, "unauthorized", err, tokenRetirePeriod)
+			if attempt < maxAttempts && tokens.hasActive() {
+				continue
+			}
+			return err
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+
+		// Если последняя попытка, возвращаем ошибку
+		if attempt == maxAttempts {
+			return upstreamError(err)
+		}
+
+		// При ожидании сброса лимита экспоненциальная пауза не нужна
+		if isRateLimited {
+			continue
+		}
+
+		// Ждем перед повторной попыткой
+		if sleepErr := sleepContext(ctx, policy.delay(attempt)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+
+	return err
+}
+
+// upstreamError помечает ошибки соединения как ErrUpstreamUnavailable, остальные возвращает как есть
+func upstreamError(err error) error {
+	var rlErr *RateLimitError
+	var apiErr *APIError
+	if errors.As(err, &rlErr) || errors.As(err, &apiErr) || errors.Is(err, ErrUpstreamUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUpstreamUnavailable, err)
+}
+
+// sleepContext ждёт d или отмены контекста.
+// Если пауза заведомо не укладывается в срок запроса, ошибка возвращается сразу.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return fmt.Errorf("waiting %s exceeds request deadline: %w", d, context.DeadlineExceeded)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
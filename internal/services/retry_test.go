@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection error", err: io.ErrUnexpectedEOF, want: true},
+		{name: "server error", err: newAPIError(http.StatusBadGateway, "https://api.github.com/users/octocat", "Bad Gateway"), want: true},
+		{name: "rate limit", err: &RateLimitError{Reset: time.Now().Add(time.Minute)}, want: true},
+		{name: "wrapped rate limit", err: fmt.Errorf("page 2: %w", &RateLimitError{Secondary: true}), want: true},
+		{name: "not found", err: newAPIError(http.StatusNotFound, "https://api.github.com/users/octocat", "Not Found"), want: false},
+		{name: "unauthorized", err: newAPIError(http.StatusUnauthorized, "https://api.github.com/user", "Bad credentials"), want: false},
+		{name: "unprocessable", err: newAPIError(http.StatusUnprocessableEntity, "https://api.github.com/users/octocat", "Validation Failed"), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("request: %w", context.DeadlineExceeded), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+		wantUnchanged   bool
+	}{
+		{name: "connection error is marked", err: io.ErrUnexpectedEOF, wantUnavailable: true},
+		{name: "server error kept", err: newAPIError(http.StatusServiceUnavailable, "https://api.github.com/users/octocat", "Unavailable"), wantUnavailable: true, wantUnchanged: true},
+		{name: "client error kept", err: newAPIError(http.StatusNotFound, "https://api.github.com/users/octocat", "Not Found"), wantUnchanged: true},
+		{name: "rate limit kept", err: &RateLimitError{Reset: time.Now()}, wantUnchanged: true},
+		{name: "already marked", err: fmt.Errorf("%w: timeout", ErrUpstreamUnavailable), wantUnavailable: true, wantUnchanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := upstreamError(tt.err)
+			if !errors.Is(got, tt.err) {
+				t.Errorf("upstreamError(%v) = %v, lost the original error", tt.err, got)
+			}
+			if unavailable := errors.Is(got, ErrUpstreamUnavailable); unavailable != tt.wantUnavailable {
+				t.Errorf("errors.Is(%v, ErrUpstreamUnavailable) = %v, want %v", got, unavailable, tt.wantUnavailable)
+			}
+			if unchanged := got == tt.err; unchanged != tt.wantUnchanged {
+				t.Errorf("upstreamError(%v) returned unchanged = %v, want %v", tt.err, unchanged, tt.wantUnchanged)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "first retry", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, attempt: 1, min: time.Second, max: time.Second},
+		{name: "doubles", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, attempt: 3, min: 4 * time.Second, max: 4 * time.Second},
+		{name: "capped", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, attempt: 10, min: 5 * time.Second, max: 5 * time.Second},
+		{name: "no overflow", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, attempt: 200, min: time.Minute, max: time.Minute},
+		{name: "jitter", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}, attempt: 2, min: time.Second, max: 2 * time.Second},
+		{name: "jitter above one", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 3}, attempt: 1, min: 0, max: time.Second},
+		{name: "default policy", policy: DefaultRetryPolicy(), attempt: 5, min: 4 * time.Second, max: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := tt.policy.delay(tt.attempt); got < tt.min || got > tt.max {
+					t.Fatalf("delay(%d) = %s, want within [%s, %s]", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
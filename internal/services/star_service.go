@@ -1,58 +1,213 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"gh-checker/internal/database"
 	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"strings"
 	"time"
 )
 
-// UpdateStars проверяет, нужно ли обновить звезды и обновляет их, если необходимо.
-// Если обновление не требуется, возвращает кэшированные данные.
-func UpdateStars(username, repository string, updateInterval time.Duration) (bool, error) {
-	logger.Info("Starting star update process for user " + username + " on repository " + repository)
-
-	// Проверка необходимости обновления звёзд
-	shouldUpdate, err := database.ShouldUpdateStars(username, repository, updateInterval)
+// UpdateStargazers обновляет кэш звёзд репозитория из GitHub, если он устарел.
+// Возвращает true, если список был загружен заново.
+func UpdateStargazers(ctx context.Context, client GitHubClient, repository string, updateInterval time.Duration) (bool, error) {
+	shouldUpdate, err := database.ShouldUpdateStargazers(ctx, repository, updateInterval)
 	if err != nil {
-		logger.Error("Error checking if stars need to be updated for user "+username, err)
+		logger.Error("Error checking if stargazers need to be updated for repository "+repository, err)
+		return false, err
+	}
+	if !shouldUpdate {
+		observeCache("stargazers", "hit")
+		return false, nil
+	}
+	if err := refreshStargazers(ctx, client, repository); err != nil {
 		return false, err
 	}
+	return true, nil
+}
+
+// errIncompleteStargazers - список звёзд репозитория не удалось загрузить целиком.
+// Такой список не кэшируется: по нему проверка пользователя без звезды в списке дала бы ложный ответ.
+var errIncompleteStargazers = errors.New("stargazer list is incomplete")
 
+// refreshStargazers загружает список звёзд репозитория и сохраняет его в кэш.
+// Список загружается заново целиком: неизменившиеся страницы отдаются из HTTP-кэша по ETag, в базу пишутся только изменения.
+// Если звёзд больше, чем GitHub отдаёт в списке, или список короче счётчика звёзд, возвращается errIncompleteStargazers.
+// Одновременные обновления одного репозитория ждут одну загрузку, и обновление учитывается только в ней.
+func refreshStargazers(ctx context.Context, client GitHubClient, repository string) error {
+	_, err := coalesce(ctx, "stargazers", strings.ToLower(repository), func(ctx context.Context) (struct{}, error) {
+		observeCache("stargazers", "refresh")
+		// Счётчик запрашиваем до списка: звёзды, добавленные во время загрузки, попадут и в список
+		expected, err := client.CountStargazers(ctx, repository)
+		if err != nil {
+			logger.Error("Error counting stargazers for repository "+repository, err)
+			return struct{}{}, err
+		}
+		if expected > maxScannedStargazers {
+			logger.Info(fmt.Sprintf("Repository %s has %d stargazers, more than GitHub lists", repository, expected))
+			return struct{}{}, fmt.Errorf("%w: repository %s has %d stargazers", errIncompleteStargazers, repository, expected)
+		}
+
+		logger.Info("Updating stargazers for repository " + repository + " via GitHub API")
+		stargazers, err := client.GetStargazers(ctx, repository)
+		if err != nil {
+			logger.Error("Error retrieving stargazers from GitHub API for repository "+repository, err)
+			return struct{}{}, err
+		}
+		if len(stargazers) < expected {
+			logger.Warn(fmt.Sprintf("Got %d of %d stargazers for repository %s, not caching the list", len(stargazers), expected, repository))
+			return struct{}{}, fmt.Errorf("%w: got %d of %d stargazers of %s", errIncompleteStargazers, len(stargazers), expected, repository)
+		}
+
+		if err := database.ReplaceStargazers(ctx, repository, stargazers); err != nil {
+			logger.Error("Error replacing stargazers for repository "+repository, err)
+			return struct{}{}, err
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// CheckStarred проверяет, поставил ли пользователь звезду на репозиторий.
+// Ответ берётся из свежего кэша звёзд репозитория или результата прошлой проверки пользователя.
+// Иначе просматривается меньший из списков: звёзды репозитория (они целиком сохраняются в кэш) или звёзды пользователя.
+func CheckStarred(ctx context.Context, client GitHubClient, user models.User, repository string, updateInterval time.Duration) (CheckResult, error) {
+	username := user.Login
+	logger.Info("Starting star check for user " + username + " on repository " + repository)
+
+	// Свежий список звёзд репозитория отвечает на проверку любого пользователя
+	shouldUpdate, err := database.ShouldUpdateStargazers(ctx, repository, updateInterval)
+	if err != nil {
+		logger.Error("Error checking if stargazers need to be updated for repository "+repository, err)
+		return CheckResult{}, err
+	}
 	if !shouldUpdate {
-		logger.Info("No update needed for user " + username + " on repository " + repository)
-		return database.IsStarred(username, repository)
+		observeCache("stargazers", "hit")
+		hasStar, err := database.IsStargazer(ctx, repository, user.ID)
+		if err != nil {
+			return CheckResult{}, err
+		}
+		return CheckResult{Result: hasStar, Strategy: StrategyCache}, nil
 	}
 
-	// Обновление звёзд через GitHub API
-	hasStar, err := CheckStar(username, repository) // Здесь должен быть вызов GitHub API
+	// Результат прошлой проверки этого пользователя
+	hasStar, cached, err := cachedStarResult(ctx, user, repository, updateInterval)
 	if err != nil {
-		logger.Error("Error retrieving stars from GitHub API for user "+username+" on repository "+repository, err)
-		return false, err
+		return CheckResult{}, err
+	}
+	if cached {
+		return CheckResult{Result: hasStar, Strategy: StrategyCache}, nil
 	}
 
-	// Очистка старых данных о звездах
-	err = database.ClearStars(username)
+	strategy, err := chooseStarStrategy(ctx, client, username, repository)
 	if err != nil {
-		logger.Error("Error clearing stars for user "+username, err)
-		return false, err
+		logger.Error("Error choosing star check strategy for user "+username+" on repository "+repository, err)
+		return CheckResult{}, err
 	}
 
-	// Добавление новых данных о звёздах
-	if hasStar {
-		err = database.AddStar(username, repository)
-		if err != nil {
-			logger.Error("Error adding star for user "+username+" on repository "+repository, err)
-			return false, err
+	if strategy == StrategyScanStargazers {
+		err := refreshStargazers(ctx, client, repository)
+		if err == nil {
+			hasStar, err := database.IsStargazer(ctx, repository, user.ID)
+			if err != nil {
+				return CheckResult{}, err
+			}
+			logger.Info("Checked user "+username+" against stargazers of repository "+repository, "strategy", strategy)
+			return CheckResult{Result: hasStar, Strategy: strategy}, nil
+		}
+		if !errors.Is(err, errIncompleteStargazers) {
+			return CheckResult{}, err
 		}
+		// По неполному списку звёзд репозитория отвечать нельзя, просматриваем звёзды пользователя
+		strategy = StrategyScanStarred
 	}
 
-	// Обновление времени последней проверки звёзд
-	err = database.UpdateLastCheckedStars(username, repository) // Используем функцию для звезд
+	hasStar, err = checkUserStars(ctx, client, user, repository)
 	if err != nil {
-		logger.Error("Error updating last checked timestamp for user "+username+" on repository "+repository, err)
+		return CheckResult{}, err
+	}
+
+	logger.Info("Successfully updated stars for user "+username+" on repository "+repository, "strategy", strategy)
+	return CheckResult{Result: hasStar, Strategy: strategy}, nil
+}
+
+// checkStarredByUser проверяет звезду по звёздам пользователя без загрузки звёзд репозитория.
+// Свежий результат прошлой проверки пользователя берётся из кэша.
+func checkStarredByUser(ctx context.Context, client GitHubClient, user models.User, repository string, updateInterval time.Duration) (CheckResult, error) {
+	hasStar, cached, err := cachedStarResult(ctx, user, repository, updateInterval)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	if cached {
+		return CheckResult{Result: hasStar, Strategy: StrategyCache}, nil
+	}
+
+	hasStar, err = checkUserStars(ctx, client, user, repository)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	return CheckResult{Result: hasStar, Strategy: StrategyScanStarred}, nil
+}
+
+// cachedStarResult возвращает результат прошлой проверки звезды пользователя, если он свежий.
+// Второе значение - найден ли свежий результат.
+func cachedStarResult(ctx context.Context, user models.User, repository string, updateInterval time.Duration) (bool, bool, error) {
+	shouldUpdate, err := database.ShouldUpdateStars(ctx, user.ID, repository, updateInterval)
+	if err != nil {
+		logger.Error("Error checking if stars need to be updated for user "+user.Login, err)
+		return false, false, err
+	}
+	if shouldUpdate {
+		return false, false, nil
+	}
+
+	observeCache("stars", "hit")
+	logger.Info("No update needed for user " + user.Login + " on repository " + repository)
+	hasStar, err := database.IsStarred(ctx, user.ID, repository)
+	if err != nil {
+		return false, false, err
+	}
+	return hasStar, true, nil
+}
+
+// checkUserStars просматривает звёзды пользователя через GitHub API и сохраняет результат
+func checkUserStars(ctx context.Context, client GitHubClient, user models.User, repository string) (bool, error) {
+	observeCache("stars", "refresh")
+	hasStar, err := client.CheckStarred(ctx, user.Login, repository)
+	if err != nil {
+		logger.Error("Error retrieving stars from GitHub API for user "+user.Login+" on repository "+repository, err)
 		return false, err
 	}
 
-	logger.Info("Successfully updated stars for user " + username + " on repository " + repository)
+	if err := saveStarResult(ctx, user, repository, hasStar); err != nil {
+		return false, err
+	}
 	return hasStar, nil
 }
+
+// saveStarResult сохраняет результат проверки звезды пользователя на одном репозитории и время проверки
+func saveStarResult(ctx context.Context, user models.User, repository string, hasStar bool) error {
+	username := user.Login
+
+	var err error
+	if hasStar {
+		err = database.AddStar(ctx, user, repository)
+	} else {
+		err = database.RemoveStar(ctx, user.ID, repository)
+	}
+	if err != nil {
+		logger.Error("Error saving star for user "+username+" on repository "+repository, err)
+		return err
+	}
+
+	// Обновление времени последней проверки звёзд
+	err = database.UpdateLastCheckedStars(ctx, user.ID, repository) // Используем функцию для звезд
+	if err != nil {
+		logger.Error("Error updating last checked timestamp for user "+username+" on repository "+repository, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gh-checker/internal/database"
+	"gh-checker/internal/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStarClient отвечает на запросы о звёздах одного репозитория.
+// Список stargazers может быть короче счётчика count, как у GitHub для больших репозиториев.
+type fakeStarClient struct {
+	GitHubClient
+	count      int
+	stargazers []models.User
+	starred    int             // Количество звёзд у любого пользователя
+	userStars  map[string]bool // Ответ CheckStarred по логину
+
+	listCalls atomic.Int32
+}
+
+func (c *fakeStarClient) CountStargazers(ctx context.Context, repository string) (int, error) {
+	return c.count, nil
+}
+
+func (c *fakeStarClient) CountStarred(ctx context.Context, username string) (int, error) {
+	return c.starred, nil
+}
+
+func (c *fakeStarClient) GetStargazers(ctx context.Context, repository string) ([]models.User, error) {
+	c.listCalls.Add(1)
+	return c.stargazers, nil
+}
+
+func (c *fakeStarClient) CheckStarred(ctx context.Context, username, repository string) (bool, error) {
+	return c.userStars[username], nil
+}
+
+func TestCheckStarred(t *testing.T) {
+	alice := models.User{ID: 1001, Login: "alice"}
+	bob := models.User{ID: 1002, Login: "bob"}
+
+	tests := []struct {
+		name          string
+		client        *fakeStarClient
+		user          models.User
+		want          bool
+		wantStrategy  Strategy
+		wantListCalls int32
+		wantCached    bool // Список звёзд репозитория сохранён как полный
+	}{
+		{
+			name:          "complete list is cached",
+			client:        &fakeStarClient{count: 2, stargazers: []models.User{alice, bob}, starred: 10},
+			user:          bob,
+			want:          true,
+			wantStrategy:  StrategyScanStargazers,
+			wantListCalls: 1,
+			wantCached:    true,
+		},
+		{
+			name:          "short list falls back to user stars",
+			client:        &fakeStarClient{count: 3, stargazers: []models.User{alice}, starred: 10, userStars: map[string]bool{"bob": true}},
+			user:          bob,
+			want:          true,
+			wantStrategy:  StrategyScanStarred,
+			wantListCalls: 1,
+		},
+		{
+			name:         "large repository is not listed",
+			client:       &fakeStarClient{count: maxScannedStargazers + 1, starred: 2 * maxScannedStargazers, userStars: map[string]bool{"bob": true}},
+			user:         bob,
+			want:         true,
+			wantStrategy: StrategyScanStarred,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repository := fmt.Sprintf("owner/check-starred-%d-%d", i, time.Now().UnixNano())
+
+			got, err := CheckStarred(ctx, tt.client, tt.user, repository, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Result != tt.want || got.Strategy != tt.wantStrategy {
+				t.Errorf("got %+v, want {Result:%v Strategy:%s}", got, tt.want, tt.wantStrategy)
+			}
+			if calls := tt.client.listCalls.Load(); calls != tt.wantListCalls {
+				t.Errorf("got %d stargazer list requests, want %d", calls, tt.wantListCalls)
+			}
+
+			stale, err := database.ShouldUpdateStargazers(ctx, repository, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached := !stale; cached != tt.wantCached {
+				t.Errorf("stargazers cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCheckStarredBatchLargeRepository(t *testing.T) {
+	client := &fakeStarClient{
+		count:     maxScannedStargazers + 1,
+		userStars: map[string]bool{"carol": true},
+	}
+	repository := fmt.Sprintf("owner/huge-%d", time.Now().UnixNano())
+	checks := []StarCheck{
+		{User: models.User{ID: 2001, Login: "carol"}, Repository: repository},
+		{User: models.User{ID: 2002, Login: "dave"}, Repository: repository},
+	}
+
+	results := CheckStarredBatch(context.Background(), client, checks, time.Hour, 2)
+	want := []bool{true, false}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("check %d: %v", i, result.Err)
+		}
+		if result.Result != want[i] || result.Strategy != StrategyScanStarred {
+			t.Errorf("check %d: got %+v, want {Result:%v Strategy:%s}", i, result.CheckResult, want[i], StrategyScanStarred)
+		}
+	}
+	if calls := client.listCalls.Load(); calls != 0 {
+		t.Errorf("got %d stargazer list requests, want 0", calls)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+)
+
+// Strategy - способ, которым был получен ответ на проверку
+type Strategy string
+
+const (
+	StrategyCache          Strategy = "cache"           // Ответ из локальной базы
+	StrategyDirect         Strategy = "direct"          // Отдельный эндпоинт GitHub для пары пользователей
+	StrategyScanStargazers Strategy = "scan_stargazers" // Просмотр звёзд репозитория
+	StrategyScanStarred    Strategy = "scan_starred"    // Просмотр звёзд пользователя
+	StrategyScanFollowers  Strategy = "scan_followers"  // Просмотр подписчиков пользователя, общий для пакета проверок
+)
+
+// CheckResult - результат проверки вместе с использованной стратегией
+type CheckResult struct {
+	Result   bool
+	Strategy Strategy
+}
+
+// maxScannedStargazers - сколько звёзд репозитория GitHub отдаёт в списке (400 страниц по 100).
+// Звёзды больших репозиториев проверяются по звёздам пользователя.
+const maxScannedStargazers = 40000
+
+// chooseStarStrategy выбирает, какой из списков дешевле просмотреть: звёзды репозитория или звёзды пользователя
+func chooseStarStrategy(ctx context.Context, client GitHubClient, username, repository string) (Strategy, error) {
+	stargazers, err := client.CountStargazers(ctx, repository)
+	if err != nil {
+		return "", err
+	}
+
+	starred, err := client.CountStarred(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info(fmt.Sprintf("Repository %s has %d stargazers, user %s starred %d repositories", repository, stargazers, username, starred))
+	if stargazers > maxScannedStargazers || starred < stargazers {
+		return StrategyScanStarred, nil
+	}
+	return StrategyScanStargazers, nil
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ресурсы GitHub, лимиты которых считаются раздельно
+const (
+	resourceCore    = "core"
+	resourceGraphQL = "graphql"
+)
+
+// defaultTokenQuota - предполагаемый остаток квоты токена, пока GitHub не сообщил настоящий
+const defaultTokenQuota = 5000
+
+// lowQuotaShare - доля лимита, ниже которой остаток квоты токена считается низким
+const lowQuotaShare = 0.1
+
+// tokenRetirePeriod - на сколько выводится из ротации токен, отклонённый с 401
+const tokenRetirePeriod = time.Hour
+
+// pooledToken - токен GitHub вместе с состоянием его лимитов
+type pooledToken struct {
+	value    string
+	source   *AppTokenSource // Источник токенов GitHub App вместо статического значения
+	requests atomic.Int64
+
+	mu           sync.Mutex
+	limits       map[string]*rateLimitState // Лимиты по ресурсам (core, graphql)
+	retiredUntil time.Time
+	retireReason string
+	retireErr    error // Ошибка GitHub, из-за которой токен выведен из ротации
+}
+
+// name возвращает безопасное для логов имя токена
+func (t *pooledToken) name() string {
+	if t.source != nil {
+		return t.source.name()
+	}
+	return maskToken(t.value)
+}
+
+// anonymous сообщает, что запросы выполняются без авторизации
+func (t *pooledToken) anonymous() bool {
+	return t.value == "" && t.source == nil
+}
+
+// credential возвращает значение токена для заголовка Authorization
+func (t *pooledToken) credential(ctx context.Context) (string, error) {
+	if t.source != nil {
+		return t.source.Token(ctx)
+	}
+	return t.value, nil
+}
+
+// limit возвращает состояние лимита токена для ресурса
+func (t *pooledToken) limit(resource string) *rateLimitState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.limits[resource]
+	if !ok {
+		state = &rateLimitState{}
+		t.limits[resource] = state
+	}
+	return state
+}
+
+// updateLimits обновляет лимит по заголовкам ответа. X-RateLimit-Resource точнее, чем ресурс, указанный клиентом.
+// Переход остатка квоты через порог lowQuotaShare в любую сторону попадает в лог на уровне Info.
+func (t *pooledToken) updateLimits(resource string, header http.Header) {
+	if headerResource := header.Get("X-RateLimit-Resource"); headerResource != "" {
+		resource = headerResource
+	}
+	state := t.limit(resource)
+	previous, _, _ := state.snapshot()
+	state.update(header)
+
+	remaining, limit, reset := state.snapshot()
+	metrics.GitHubRateLimitRemaining.WithLabelValues(t.name(), resource).Set(float64(remaining))
+
+	attrs := []any{"token", t.name(), "resource", resource, "requests", t.requests.Load(), "remaining", remaining, "limit", limit, "reset", reset}
+	switch quotaTransition(previous, remaining, limit) {
+	case quotaLow:
+		logger.Info("GitHub token quota is running low", attrs...)
+	case quotaRestored:
+		logger.Info("GitHub token quota restored", attrs...)
+	default:
+		logger.Debug("GitHub token usage", attrs...)
+	}
+}
+
+// Переходы остатка квоты через порог lowQuotaShare
+const (
+	quotaLow      = "low"
+	quotaRestored = "restored"
+)
+
+// quotaTransition сообщает, пересёк ли остаток квоты порог lowQuotaShare: quotaLow, quotaRestored или пустая строка
+func quotaTransition(previous, remaining, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	threshold := int(float64(limit) * lowQuotaShare)
+	switch {
+	case previous >= threshold && remaining < threshold:
+		return quotaLow
+	case previous < threshold && remaining >= threshold:
+		return quotaRestored
+	}
+	return ""
+}
+
+// TokenPool распределяет запросы между несколькими токенами GitHub
+type TokenPool struct {
+	mu     sync.Mutex
+	tokens []*pooledToken
+}
+
+// NewTokenPool создаёт пул из переданных токенов. Пустой пул выполняет запросы без авторизации.
+func NewTokenPool(tokens ...string) *TokenPool {
+	pool := &TokenPool{}
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		pool.tokens = append(pool.tokens, &pooledToken{value: token, limits: make(map[string]*rateLimitState)})
+	}
+
+	if len(pool.tokens) == 0 {
+		pool.tokens = append(pool.tokens, &pooledToken{limits: make(map[string]*rateLimitState)})
+	}
+
+	logger.Info(fmt.Sprintf("GitHub token pool initialized with %d tokens", len(pool.tokens)))
+	return pool
+}
+
+// NewAppTokenPool создаёт пул, выполняющий запросы от имени установки GitHub App
+func NewAppTokenPool(source *AppTokenSource) *TokenPool {
+	logger.Info("GitHub token pool initialized with GitHub App installation token", "source", source.name())
+	return &TokenPool{tokens: []*pooledToken{{source: source, limits: make(map[string]*rateLimitState)}}}
+}
+
+// acquire выбирает токен с наибольшим запасом квоты для ресурса.
+// Если все токены исчерпаны, ждёт ближайшего сброса, но не дольше maxWait, иначе возвращает RateLimitError.
+// Если все токены отклонены GitHub, возвращает ошибку последнего отказа.
+func (p *TokenPool) acquire(ctx context.Context, resource string, maxWait time.Duration) (*pooledToken, error) {
+	for {
+		token, err := p.pick(resource)
+		if token != nil {
+			token.requests.Add(1)
+			return token, nil
+		}
+		var rlErr *RateLimitError
+		if !errors.As(err, &rlErr) {
+			return nil, err
+		}
+
+		wait := rlErr.RetryAfter()
+		if wait > maxWait {
+			logger.Warn("All GitHub tokens are exhausted, rejecting request", "resource", resource, "reset", rlErr.Reset, "secondary", rlErr.Secondary)
+			return nil, rlErr
+		}
+
+		logger.Warn(fmt.Sprintf("All GitHub tokens are exhausted, waiting %s for reset", wait), "resource", resource)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pick возвращает доступный токен с наибольшим остатком квоты.
+// Если доступных нет, возвращает RateLimitError с ближайшим сбросом лимита,
+// а если все токены выведены из ротации - ошибку, с которой GitHub отклонил последний из них.
+func (p *TokenPool) pick(resource string) (*pooledToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var best *pooledToken
+	bestRemaining := -1
+	var earliest *RateLimitError
+	var lastRetired time.Time
+	var retireErr error
+
+	for _, token := range p.tokens {
+		token.mu.Lock()
+		retiredUntil, tokenErr := token.retiredUntil, token.retireErr
+		token.mu.Unlock()
+
+		if now.Before(retiredUntil) {
+			if retiredUntil.After(lastRetired) {
+				lastRetired, retireErr = retiredUntil, tokenErr
+			}
+			continue
+		}
+
+		state := token.limit(resource)
+		if rlErr := state.check(); rlErr != nil {
+			earliest = earlierLimit(earliest, rlErr)
+			continue
+		}
+
+		remaining, _, _ := state.snapshot()
+		if remaining > bestRemaining {
+			best, bestRemaining = token, remaining
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if earliest != nil {
+		return nil, earliest
+	}
+	return nil, retireErr
+}
+
+// retire временно выводит токен из ротации из-за ошибки err.
+// Токен GitHub App не выводится: вместо этого при следующем запросе будет получен новый.
+func (p *TokenPool) retire(token *pooledToken, reason string, err error, period time.Duration) {
+	if token.source != nil {
+		token.source.invalidate()
+		logger.Warn("GitHub App installation token rejected, will request a new one", "token", token.name(), "reason", reason)
+		return
+	}
+
+	token.mu.Lock()
+	defer token.mu.Unlock()
+
+	token.retiredUntil = time.Now().Add(period)
+	token.retireReason = reason
+	token.retireErr = err
+	logger.Warn("GitHub token retired", "token", token.name(), "reason", reason, "until", token.retiredUntil)
+}
+
+// hasActive сообщает, остались ли в пуле токены, не выведенные из ротации
+func (p *TokenPool) hasActive() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range p.tokens {
+		token.mu.Lock()
+		active := !now.Before(token.retiredUntil)
+		token.mu.Unlock()
+		if active {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenUsage - статистика использования токена
+type TokenUsage struct {
+	Token        string // Замаскированное значение токена
+	Requests     int64
+	RetiredUntil time.Time // Нулевое значение - токен в ротации
+	RetireReason string
+	Limits       map[string]RateLimitUsage // Состояние лимитов по ресурсам
+}
+
+// RateLimitUsage - последнее известное состояние лимита
+type RateLimitUsage struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// TokenRateLimit - состояние лимита одного токена пула, полученное запросом к GitHub
+type TokenRateLimit struct {
+	Token string // Безопасное для логов имя токена
+	RateLimitUsage
+	Err error // Ошибка проверки токена, например ErrUnauthorized
+}
+
+// checkEachToken выполняет check для каждого токена пула параллельно.
+// Если GitHub сообщает, что лимит отключён, возвращает ErrRateLimitDisabled.
+func (p *TokenPool) checkEachToken(ctx context.Context, check func(ctx context.Context, token *pooledToken) (RateLimitUsage, error)) ([]TokenRateLimit, error) {
+	p.mu.Lock()
+	tokens := append([]*pooledToken(nil), p.tokens...)
+	p.mu.Unlock()
+
+	results := make([]TokenRateLimit, len(tokens))
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			usage, err := check(ctx, token)
+			results[i] = TokenRateLimit{Token: token.name(), RateLimitUsage: usage, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if errors.Is(result.Err, ErrRateLimitDisabled) {
+			return nil, ErrRateLimitDisabled
+		}
+	}
+	return results, nil
+}
+
+// Usage возвращает статистику по всем токенам пула
+func (p *TokenPool) Usage() []TokenUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]TokenUsage, 0, len(p.tokens))
+	for _, token := range p.tokens {
+		token.mu.Lock()
+		item := TokenUsage{
+			Token:    token.name(),
+			Requests: token.requests.Load(),
+			Limits:   make(map[string]RateLimitUsage, len(token.limits)),
+		}
+		if now.Before(token.retiredUntil) {
+			item.RetiredUntil = token.retiredUntil
+			item.RetireReason = token.retireReason
+		}
+		for resource, state := range token.limits {
+			remaining, limit, reset := state.snapshot()
+			item.Limits[resource] = RateLimitUsage{Limit: limit, Remaining: remaining, Reset: reset}
+		}
+		token.mu.Unlock()
+
+		usage = append(usage, item)
+	}
+	return usage
+}
+
+// earlierLimit возвращает лимит, который сбросится раньше
+func earlierLimit(current, candidate *RateLimitError) *RateLimitError {
+	if current == nil || candidate.Reset.Before(current.Reset) {
+		return candidate
+	}
+	return current
+}
+
+// maskToken оставляет от токена только последние символы
+func maskToken(token string) string {
+	if token == "" {
+		return "anonymous"
+	}
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Отклонённый токен не должен превращаться в ошибку лимита запросов
+func TestRetiredTokenReportsUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, NewTokenPool("ghp_invalid"), nil)
+	for i := 0; i < 2; i++ {
+		_, err := client.GetUser(context.Background(), "octocat")
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("call %d: got %v, want ErrUnauthorized", i+1, err)
+		}
+		if errors.Is(err, ErrRateLimited) {
+			t.Fatalf("call %d: unauthorized token reported as rate limited: %v", i+1, err)
+		}
+	}
+}
+
+func TestTokenPoolPick(t *testing.T) {
+	unauthorized := newAPIError(http.StatusUnauthorized, "https://api.github.com/user", "Bad credentials")
+	reset := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name          string
+		setup         func(pool *TokenPool)
+		wantToken     string
+		wantRateLimit bool
+		wantUnauth    bool
+	}{
+		{
+			name:      "picks token with most quota",
+			setup:     func(pool *TokenPool) { pool.tokens[0].limit(resourceCore).set(10, 5000, reset) },
+			wantToken: "****-two",
+		},
+		{
+			name: "skips retired token",
+			setup: func(pool *TokenPool) {
+				pool.retire(pool.tokens[1], "unauthorized", unauthorized, time.Hour)
+			},
+			wantToken: "****-one",
+		},
+		{
+			name: "all tokens retired",
+			setup: func(pool *TokenPool) {
+				pool.retire(pool.tokens[0], "unauthorized", unauthorized, time.Hour)
+				pool.retire(pool.tokens[1], "unauthorized", unauthorized, time.Hour)
+			},
+			wantUnauth: true,
+		},
+		{
+			name: "rate limited token outlives retired one",
+			setup: func(pool *TokenPool) {
+				pool.tokens[0].limit(resourceCore).block(&RateLimitError{Reset: reset})
+				pool.retire(pool.tokens[1], "unauthorized", unauthorized, time.Hour)
+			},
+			wantRateLimit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewTokenPool("token-one", "token-two")
+			tt.setup(pool)
+
+			token, err := pool.pick(resourceCore)
+			if tt.wantToken != "" {
+				if token == nil || token.name() != tt.wantToken {
+					t.Fatalf("got token %v (err %v), want %s", token, err, tt.wantToken)
+				}
+				return
+			}
+			if token != nil {
+				t.Fatalf("got token %s, want error", token.name())
+			}
+			if got := errors.Is(err, ErrRateLimited); got != tt.wantRateLimit {
+				t.Errorf("errors.Is(err, ErrRateLimited) = %v, want %v (err %v)", got, tt.wantRateLimit, err)
+			}
+			if got := errors.Is(err, ErrUnauthorized); got != tt.wantUnauth {
+				t.Errorf("errors.Is(err, ErrUnauthorized) = %v, want %v (err %v)", got, tt.wantUnauth, err)
+			}
+		})
+	}
+}
+
+// failingCache отмечает любое обращение к кэшу ответов
+type failingCache struct {
+	t *testing.T
+}
+
+func (c failingCache) Get(ctx context.Context, url string) (CachedResponse, bool) {
+	c.t.Errorf("unexpected cache lookup for %s", url)
+	return CachedResponse{}, false
+}
+
+func (c failingCache) Put(ctx context.Context, url string, resp CachedResponse) {
+	c.t.Errorf("unexpected cache write for %s", url)
+}
+
+func (c failingCache) Touch(ctx context.Context, url string) {
+	c.t.Errorf("unexpected cache touch for %s", url)
+}
+
+// Проверка готовности запрашивает лимит каждого токена в обход кэша ответов
+func TestRateLimitChecksEveryToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/rate_limit" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") == "token ghp_revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Header().Set("ETag", `"rate-limit"`)
+		w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":4321,"reset":1700000000}}}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, NewTokenPool("ghp_valid", "ghp_revoked"), nil)
+	client.SetResponseCache(failingCache{t: t})
+
+	results, err := client.RateLimit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Err != nil || results[0].Remaining != 4321 || results[0].Limit != 5000 {
+		t.Errorf("valid token: got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrUnauthorized) {
+		t.Errorf("revoked token: got %+v, want ErrUnauthorized", results[1])
+	}
+	if results[0].Token == results[1].Token || results[1].Token == "" {
+		t.Errorf("tokens are not named: %q, %q", results[0].Token, results[1].Token)
+	}
+}
+
+func TestQuotaTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  int
+		remaining int
+		limit     int
+		want      string
+	}{
+		{name: "plenty left", previous: 4000, remaining: 3999, limit: 5000, want: ""},
+		{name: "crosses threshold", previous: 500, remaining: 499, limit: 5000, want: quotaLow},
+		{name: "first response already low", previous: defaultTokenQuota, remaining: 10, limit: 5000, want: quotaLow},
+		{name: "stays low", previous: 499, remaining: 498, limit: 5000, want: ""},
+		{name: "reset restores quota", previous: 12, remaining: 5000, limit: 5000, want: quotaRestored},
+		{name: "unknown limit", previous: 100, remaining: 0, limit: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quotaTransition(tt.previous, tt.remaining, tt.limit); got != tt.want {
+				t.Errorf("quotaTransition(%d, %d, %d) = %q, want %q", tt.previous, tt.remaining, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
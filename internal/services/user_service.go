@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gh-checker/internal/database"
+	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/models"
+	"strings"
+	"time"
+)
+
+// ResolveUser находит пользователя по ID или логину. ID имеет приоритет: он не меняется при переименовании аккаунта.
+// Сопоставление логина и ID берётся из базы, если оно проверялось не раньше updateInterval назад.
+func ResolveUser(ctx context.Context, client GitHubClient, login string, id int64, updateInterval time.Duration) (models.User, error) {
+	var cached models.User
+	var lastUpdated time.Time
+	var err error
+	if id != 0 {
+		cached, lastUpdated, err = database.GetUserByID(ctx, id)
+	} else {
+		cached, lastUpdated, err = database.GetUserByLogin(ctx, login)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return models.User{}, err
+	}
+	if err == nil && time.Since(lastUpdated) <= updateInterval {
+		return cached, nil
+	}
+
+	// Одновременные запросы об одном пользователе ждут один ответ GitHub
+	key := "login:" + strings.ToLower(login)
+	if id != 0 {
+		key = fmt.Sprintf("id:%d", id)
+	}
+	user, err := coalesce(ctx, "users", key, func(ctx context.Context) (models.User, error) {
+		var user models.User
+		var err error
+		if id != 0 {
+			user, err = client.GetUserByID(ctx, id)
+		} else {
+			user, err = client.GetUser(ctx, login)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error resolving user %q (id %d)", login, id), err)
+			return models.User{}, err
+		}
+
+		if err := database.SaveUser(ctx, user); err != nil {
+			return models.User{}, err
+		}
+		return user, nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if cached.ID != 0 && !strings.EqualFold(cached.Login, user.Login) {
+		logger.Info(fmt.Sprintf("User %d was renamed from %s to %s", user.ID, cached.Login, user.Login))
+	}
+	return user, nil
+}
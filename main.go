@@ -1,19 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"gh-checker/internal/config"
 	"gh-checker/internal/database"
 	"gh-checker/internal/handlers"
 	"gh-checker/internal/lib/logger"
+	"gh-checker/internal/lib/metrics"
+	"gh-checker/internal/models"
 	"gh-checker/internal/services"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// Значения по умолчанию для настроек сервера
+const (
+	defaultAddr            = ":8080"
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// readHeaderTimeout - сколько ждать заголовков запроса: медленный клиент не удержит соединение и остановку сервера
+const readHeaderTimeout = 10 * time.Second
+
 func main() {
 	// Загружаем конфигурацию
 	if err := config.LoadConfig("config.yaml"); err != nil {
@@ -42,13 +60,30 @@ func main() {
 	// Теперь можно использовать кастомный логгер
 	logger.Info("Configuration and logger initialized")
 
-	// Проверка наличия GitHub API Key
-	githubAPIKey := config.AppConfig.GitHub.APIKey
-	if githubAPIKey == "" {
-		logger.Error("GitHub API key not found in config file", nil)
+	// URL репозиториев на GitHub Enterprise Server принимаются наравне с github.com
+	if host := services.EnterpriseHost(config.AppConfig.GitHub.BaseURL); host != "" {
+		models.AllowRepositoryHost(host)
+	}
+
+	// Проверка наличия GitHub API Key или настроек GitHub App
+	tokens, err := newTokenPool()
+	if err != nil {
+		logger.Error("Failed to configure GitHub authentication", err)
 		os.Exit(1) // Завершение программы при отсутствии API ключа
 	}
-	services.SetGitHubAPIKey(githubAPIKey)
+	// Клиенты одного бэкенда переиспользуются, чтобы учитывать общий лимит запросов
+	githubClients := make(map[string]services.GitHubClient)
+	followerClient, err := githubClientFor(githubClients, config.AppConfig.GitHub.Backends.Followers, tokens)
+	if err != nil {
+		logger.Error("Invalid GitHub backend for followers", err)
+		os.Exit(1)
+	}
+	starClient, err := githubClientFor(githubClients, config.AppConfig.GitHub.Backends.Stars, tokens)
+	if err != nil {
+		logger.Error("Invalid GitHub backend for stars", err)
+		os.Exit(1)
+	}
+	h := handlers.NewHandler(followerClient, starClient, tokens)
 
 	// Инициализация базы данных
 	if err := database.InitDB(config.AppConfig.Database.Path); err != nil {
@@ -57,16 +92,191 @@ func main() {
 	}
 	logger.Info("Database initialized")
 
-	// Настройка роутера
+	err = runServer(newRouter(h))
+	if closeErr := database.CloseDB(); closeErr != nil {
+		logger.Error("Failed to close database", closeErr)
+	}
+	if err != nil {
+		logger.Error("Server failed", err)
+		logger.CloseLogger()
+		os.Exit(1) // Завершение программы при ошибке старта сервера
+	}
+	logger.Info("Server stopped")
+}
+
+// newRouter регистрирует маршруты сервиса. Каждый маршрут должен быть описан в internal/handlers/openapi.json.
+func newRouter(h *handlers.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
+	r.Use(metrics.Middleware)
+	if timeout := config.AppConfig.Server.RequestTimeout; timeout > 0 {
+		// Срок запроса передаётся через r.Context() в обращения к GitHub и базе данных
+		r.Use(middleware.Timeout(timeout))
+	}
 
-	r.Post("/api/subscribe", handlers.SubscribeHandler) // TODO: сделать на /check-followers
-	r.Post("/check-star", handlers.StarCheckHandler)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/users/{user}/followers/{follower}", h.FollowerCheckHandler)
+		r.Get("/repos/{owner}/{repo}/stargazers/{user}", h.StargazerCheckHandler)
+		r.Post("/batch", h.BatchHandler)
+	})
 
-	logger.Info("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		logger.Error("Server failed to start", err)
-		os.Exit(1) // Завершение программы при ошибке старта сервера
+	// Старые маршруты оставлены для совместимости
+	r.With(handlers.Deprecated).Post("/api/subscribe", h.SubscribeHandler)
+	r.With(handlers.Deprecated).Post("/check-star", h.StarCheckHandler)
+	r.With(handlers.AdminAuth(config.AppConfig.Server.AdminToken)).Get("/admin/tokens", h.TokenUsageHandler)
+
+	r.Get("/healthz", handlers.HealthHandler)
+	r.Get("/readyz", h.ReadyHandler)
+	r.Get("/version", handlers.VersionHandler)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	r.Get("/openapi.json", handlers.OpenAPIHandler)
+	r.Get("/docs", handlers.DocsHandler)
+	r.Method(http.MethodGet, "/docs/assets/*", handlers.SwaggerUIAssetsHandler())
+	return r
+}
+
+// runServer обслуживает запросы до SIGINT или SIGTERM, затем перестаёт принимать новые соединения
+// и ждёт завершения текущих запросов не дольше server.shutdown_timeout.
+// Запросы, не успевшие завершиться, отменяются через контекст.
+func runServer(handler http.Handler) error {
+	addr := config.AppConfig.Server.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	shutdownTimeout := config.AppConfig.Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	// Базовый контекст всех запросов: его отмена прерывает обращения к GitHub и базе данных
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return requestsCtx },
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting on " + addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+		// Повторный сигнал завершит процесс сразу
+		stop()
+	}
+
+	logger.Info("Shutting down, draining in-flight requests", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Requests did not finish in time, cancelling them", err)
+		cancelRequests()
+		server.Close()
+	}
+	return nil
+}
+
+// newTokenPool создаёт пул токенов GitHub: из GitHub App, если оно настроено, иначе из API ключей
+func newTokenPool() (*services.TokenPool, error) {
+	cfg := config.AppConfig.GitHub
+
+	if cfg.App.AppID != 0 {
+		privateKey, err := os.ReadFile(cfg.App.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read github app private key: %w", err)
+		}
+		source, err := services.NewAppTokenSource(cfg.BaseURL, cfg.App.AppID, cfg.App.InstallationID, privateKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		return services.NewAppTokenPool(source), nil
+	}
+
+	apiKeys := cfg.APIKeys
+	if cfg.APIKey != "" {
+		apiKeys = append([]string{cfg.APIKey}, apiKeys...)
+	}
+	if len(apiKeys) == 0 {
+		return nil, errors.New("github.api_key, github.api_keys and github.app are not configured")
+	}
+	return services.NewTokenPool(apiKeys...), nil
+}
+
+// githubClientFor возвращает клиент GitHub для указанного в конфигурации бэкенда, создавая его при первом обращении
+func githubClientFor(clients map[string]services.GitHubClient, backend string, tokens *services.TokenPool) (services.GitHubClient, error) {
+	if backend == "" {
+		backend = services.BackendREST
+	}
+	if client, ok := clients[backend]; ok {
+		return client, nil
+	}
+
+	client, err := newGitHubClient(backend, tokens)
+	if err != nil {
+		return nil, err
+	}
+	clients[backend] = client
+	return client, nil
+}
+
+// newGitHubClient создаёт клиент GitHub для указанного бэкенда
+func newGitHubClient(backend string, tokens *services.TokenPool) (services.GitHubClient, error) {
+	cfg := config.AppConfig.GitHub
+
+	switch backend {
+	case services.BackendREST:
+		client := services.NewHTTPClient(cfg.BaseURL, tokens, nil)
+		if cfg.APIVersion != "" {
+			client.SetAPIVersion(cfg.APIVersion)
+		}
+		if cfg.MaxRateLimitWait > 0 {
+			client.SetMaxRateLimitWait(cfg.MaxRateLimitWait)
+		}
+		if cfg.PageConcurrency > 0 {
+			client.SetPageConcurrency(cfg.PageConcurrency)
+		}
+		client.SetRetryPolicy(retryPolicy())
+		client.SetResponseCache(services.NewDatabaseResponseCache(config.AppConfig.Database.HTTPCacheTTL, config.AppConfig.Database.HTTPCacheMaxEntries))
+		return client, nil
+	case services.BackendGraphQL:
+		client := services.NewGraphQLClient(cfg.BaseURL, tokens, nil)
+		if cfg.MaxRateLimitWait > 0 {
+			client.SetMaxRateLimitWait(cfg.MaxRateLimitWait)
+		}
+		client.SetRetryPolicy(retryPolicy())
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown GitHub backend %q", backend)
+	}
+}
+
+// retryPolicy возвращает политику повторов из конфигурации, подставляя значения по умолчанию для незаданных полей
+func retryPolicy() services.RetryPolicy {
+	cfg := config.AppConfig.GitHub.Retry
+	policy := services.DefaultRetryPolicy()
+
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BaseDelay > 0 {
+		policy.BaseDelay = cfg.BaseDelay
+	}
+	if cfg.MaxDelay > 0 {
+		policy.MaxDelay = cfg.MaxDelay
+	}
+	if cfg.Jitter > 0 {
+		policy.Jitter = cfg.Jitter
 	}
+	return policy
 }
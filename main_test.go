@@ -0,0 +1,14 @@
+package main
+
+import (
+	"gh-checker/internal/handlers"
+	"testing"
+)
+
+// Маршруты роутера должны совпадать с описанными в openapi.json
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	r := newRouter(handlers.NewHandler(nil, nil, nil))
+	if err := handlers.CheckSpecRoutes(r); err != nil {
+		t.Fatal(err)
+	}
+}